@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// PodLabel values: what the pod prefix of a line shows.
+const (
+	podLabelPod = "pod"
+	podLabelApp = "app"
+)
+
+// AppNameRule derives an entry's app name, the workload shown in group hop
+// paths and matched by app= conditions. Rules set either Pattern, a regular
+// expression matched against the pod name whose "app" group (or, without one,
+// whole match) is the app name, e.g. "^(?P<app>.+)-[0-9]{8}-[a-z0-9]{5}$", or
+// Field, a field whose value is the app name, e.g. "service.name".
+type AppNameRule struct {
+	Pattern string
+	Field   string
+
+	pattern *regexp.Regexp
+}
+
+// compileAppNameRules compiles the rules' patterns once, when the config is
+// loaded. Rules that fail to compile are dropped and described in problems.
+func compileAppNameRules(rules []AppNameRule) (compiled []AppNameRule, problems []string) {
+	for i, rule := range rules {
+		switch {
+		case rule.Pattern != "" && rule.Field != "":
+			problems = append(problems, fmt.Sprintf("AppNames[%d]: set either Pattern or Field, not both", i))
+			continue
+		case rule.Pattern != "":
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("AppNames[%d]: %v", i, err))
+				continue
+			}
+			rule.pattern = pattern
+		case rule.Field == "":
+			problems = append(problems, fmt.Sprintf("AppNames[%d]: set Pattern or Field", i))
+			continue
+		}
+		compiled = append(compiled, rule)
+	}
+	return compiled, problems
+}
+
+// deriveAppName returns the app name from the first rule that gives one, else
+// the pod name without its Kubernetes suffix (see trimPodHash).
+func deriveAppName(rules []AppNameRule, logEntry *LogEntry) string {
+	for _, rule := range rules {
+		if app := rule.apply(logEntry); app != "" {
+			return app
+		}
+	}
+	return trimPodHash(logEntry.PodID)
+}
+
+func (r AppNameRule) apply(logEntry *LogEntry) string {
+	if r.pattern == nil {
+		return logEntry.Fields[r.Field]
+	}
+
+	match := r.pattern.FindStringSubmatch(logEntry.PodID)
+	if match == nil {
+		return ""
+	}
+	if group := r.pattern.SubexpIndex("app"); group >= 0 {
+		return match[group]
+	}
+	return match[0]
+}
+
+// entryApp returns the entry's app name. Entries that weren't read from input
+// have none set and fall back on their pod name.
+func entryApp(logEntry *LogEntry) string {
+	if logEntry.App != "" {
+		return logEntry.App
+	}
+	return trimPodHash(logEntry.PodID)
+}
+
+// podLabel is what the pod prefix of a line shows: the pod name, or its app
+// name with PodLabel "app".
+func podLabel(config Config, logEntry *LogEntry) string {
+	if config.PodLabel == podLabelApp && logEntry.PodID != "" {
+		if app := entryApp(logEntry); app != "" {
+			return app
+		}
+	}
+	return logEntry.PodID
+}
+
+// validatePodLabel describes a PodLabel value that isn't known.
+func validatePodLabel(label string) []string {
+	switch label {
+	case "", podLabelPod, podLabelApp:
+		return nil
+	}
+	return []string{fmt.Sprintf("PodLabel: unknown label %q, must be pod or app", label)}
+}
@@ -0,0 +1,81 @@
+package main
+
+import "testing"
+
+func TestDeriveAppName(t *testing.T) {
+	rules, problems := compileAppNameRules([]AppNameRule{
+		{Field: "service.name"},
+		{Pattern: `^(?P<app>.+)-[0-9]{8}-[a-z0-9]{5}$`},
+		{Pattern: `^rollout-[a-z]+`},
+	})
+	if len(problems) != 0 {
+		t.Fatalf("compileAppNameRules() problems = %v", problems)
+	}
+
+	tests := []struct {
+		name   string
+		podID  string
+		fields map[string]string
+		want   string
+	}{
+		{"field rule wins", "booking-api-7d8f9b6c5-x2k4p", map[string]string{"service.name": "bookings"}, "bookings"},
+		{"named group of a pattern", "nightly-backup-28734560-x7k2p", nil, "nightly-backup"},
+		{"whole match without an app group", "rollout-canary-abc", nil, "rollout-canary"},
+		{"falls back on trimming the pod hash", "booking-api-7d8f9b6c5-x2k4p", nil, "booking-api"},
+		{"field rule without a pod", "", map[string]string{"service.name": "bookings"}, "bookings"},
+		{"nothing to go on", "", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &LogEntry{PodID: tt.podID, Fields: tt.fields}
+			if got := deriveAppName(rules, entry); got != tt.want {
+				t.Errorf("deriveAppName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileAppNameRulesProblems(t *testing.T) {
+	rules, problems := compileAppNameRules([]AppNameRule{
+		{Pattern: "("},
+		{Pattern: "x", Field: "service.name"},
+		{},
+		{Field: "service.name"},
+	})
+
+	if len(problems) != 3 || len(rules) != 1 {
+		t.Errorf("got %d rules and problems %v, want 1 rule and 3 problems", len(rules), problems)
+	}
+}
+
+func TestPodLabel(t *testing.T) {
+	config := *newDefaultConfig()
+	entry := &LogEntry{PodID: "booking-api-7d8f9b6c5-x2k4p", App: "bookings"}
+
+	if got := podLabel(config, entry); got != entry.PodID {
+		t.Errorf("podLabel() = %q, want the pod name by default", got)
+	}
+
+	config.PodLabel = podLabelApp
+	if got := podLabel(config, entry); got != "bookings" {
+		t.Errorf("podLabel() = %q, want the app name with PodLabel app", got)
+	}
+
+	if got := podLabel(config, &LogEntry{App: "bookings"}); got != "" {
+		t.Errorf("podLabel() = %q, want no label for lines without a pod", got)
+	}
+}
+
+func TestHopPathUsesAppNames(t *testing.T) {
+	entries := []*LogEntry{
+		{PodID: "gateway-7d8f9b6c5-x2k4p", App: "edge"},
+		{App: "bookings"},
+		{PodID: "gateway-7d8f9b6c5-abcde", App: "edge"},
+	}
+
+	got := hopPath(entries)
+	if len(got) != 2 || got[0] != "edge" || got[1] != "bookings" {
+		t.Errorf("hopPath() = %v, want [edge bookings]", got)
+	}
+}
@@ -6,34 +6,120 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Args struct {
-	IncludedFields map[string]struct{}
-	ExcludedFields map[string]struct{}
-	Truncate       *Truncate
-	WhereFields    map[string]string
-	HighlightKey   string
-	HighlightValue string
-	LogLevel       string
-	MinLogLevel    string
-	MaxLogLevel    string
-	AllFields      bool
-	GroupBy        []string
-}
-
-func parseArgs(logLevelToSeverity map[string]int) (*Args, error) {
+	IncludedFields  map[string]struct{}
+	ExcludedFields  map[string]struct{}
+	Truncate        *Truncate
+	WhereFields     map[string]string
+	HighlightKeys   []Highlight
+	HighlightValues []Highlight
+	WhereHighlights []Highlight
+	LogLevel        string
+	MinLogLevel     string
+	MaxLogLevel     string
+	AllFields       bool
+	GroupBy         [][]string
+	Output          string
+	Columns         []string
+	NoHeader        bool
+	TimeFormat      *TimeFormat
+	Width           int
+	Color           string
+	MessageLayout   string
+	StackLines      int
+	Hyperlinks      string
+	GroupIdle       time.Duration
+	GroupEnd        *Condition
+	GroupMaxLines   int
+	Waterfall       bool
+	GroupWhere      *Condition
+	GroupMinLevel   string
+	GroupSort       *GroupSort
+	GroupLimit      int
+	Stats           bool
+	StatsOnly       bool
+	StatsTop        int
+}
+
+const (
+	outputText = "text"
+	outputHTML = "html"
+	outputCSV  = "csv"
+	outputTSV  = "tsv"
+)
+
+func parseArgs(config Config) (*Args, error) {
 	args := &Args{}
+	logLevelToSeverity := config.LogLevelToSeverity
 
 	args.IncludedFields = parseFieldsArg()
 	args.ExcludedFields = parseExceptArg()
 	args.Truncate = parseTruncArg()
 	args.WhereFields = parseWhereArg()
-	args.HighlightKey = parseHighlightKey()
-	args.HighlightValue = parseHighlightValue()
+	args.HighlightKeys, args.HighlightValues = newHighlights(config, highlightKeys, highlightValues)
+	args.WhereHighlights = newWhereHighlights(config, args.WhereFields)
 	args.AllFields = parseAllFieldsArg()
 	args.GroupBy = parseGroupByArg()
 
+	groupIdle, groupEnd, err := parseGroupStreamArgs()
+	if err != nil {
+		return nil, err
+	}
+	args.GroupIdle = groupIdle
+	args.GroupEnd = groupEnd
+	if groupMaxLinesFlag != nil {
+		args.GroupMaxLines = *groupMaxLinesFlag
+	}
+	args.Waterfall = waterfallFlag != nil && *waterfallFlag
+
+	output, err := parseOutputArg()
+	if err != nil {
+		return nil, err
+	}
+	args.Output = output
+	args.Columns = parseColumnsArg()
+
+	colorMode, err := parseColorArg()
+	if err != nil {
+		return nil, err
+	}
+	args.Color = colorMode
+
+	hyperlinks, err := parseHyperlinksArg()
+	if err != nil {
+		return nil, err
+	}
+	args.Hyperlinks = hyperlinks
+
+	args.NoHeader = noHeader != nil && *noHeader
+
+	messageLayout, err := parseMessageLayoutArg(config, args.Output)
+	if err != nil {
+		return nil, err
+	}
+	args.MessageLayout = messageLayout
+
+	timeFormat, err := parseTimeFormatArg(config)
+	if err != nil {
+		return nil, err
+	}
+	args.TimeFormat = timeFormat
+
+	width, err := parseWidthArg()
+	if err != nil {
+		return nil, err
+	}
+	args.Width = width
+
+	stackLines, err := parseStackLinesArg(config)
+	if err != nil {
+		return nil, err
+	}
+	args.StackLines = stackLines
+
 	level, err := parseLogLevel(logLevelToSeverity)
 	if err != nil {
 		return nil, err
@@ -52,6 +138,33 @@ func parseArgs(logLevelToSeverity map[string]int) (*Args, error) {
 	}
 	args.MaxLogLevel = maxLevel
 
+	groupWhere, groupMinLevel, err := parseGroupFilterArgs(logLevelToSeverity)
+	if err != nil {
+		return nil, err
+	}
+	args.GroupWhere = groupWhere
+	args.GroupMinLevel = groupMinLevel
+
+	groupSort, err := parseGroupSortArg(isGroupStreaming(*args))
+	if err != nil {
+		return nil, err
+	}
+	args.GroupSort = groupSort
+
+	groupLimit, err := parseGroupLimitArg(isGroupStreaming(*args))
+	if err != nil {
+		return nil, err
+	}
+	args.GroupLimit = groupLimit
+
+	stats, statsOnly, statsTop, err := parseStatsArgs()
+	if err != nil {
+		return nil, err
+	}
+	args.Stats = stats
+	args.StatsOnly = statsOnly
+	args.StatsTop = statsTop
+
 	if isDebug() {
 		fmt.Printf("CLI Arguments:\n")
 		fmt.Printf("  Raw args/flags: %+v\n", os.Args)
@@ -60,13 +173,33 @@ func parseArgs(logLevelToSeverity map[string]int) (*Args, error) {
 		fmt.Printf("    Excluded fields: %+v\n", args.ExcludedFields)
 		fmt.Printf("    Truncate: %+v\n", args.Truncate)
 		fmt.Printf("    Where: %+v\n", args.WhereFields)
-		fmt.Printf("    Highlight key: %s\n", args.HighlightKey)
-		fmt.Printf("    Highlight value: %s\n", args.HighlightValue)
+		fmt.Printf("    Highlight keys: %+v\n", args.HighlightKeys)
+		fmt.Printf("    Highlight values: %+v\n", args.HighlightValues)
 		fmt.Printf("    LogLevel: %s\n", args.LogLevel)
 		fmt.Printf("    MinLogLevel: %s\n", args.MinLogLevel)
 		fmt.Printf("    MaxLogLevel: %s\n", args.MaxLogLevel)
 		fmt.Printf("    AllFields: %t\n", args.AllFields)
 		fmt.Printf("    GroupBy: %+v\n", args.GroupBy)
+		fmt.Printf("    GroupIdle: %s\n", args.GroupIdle)
+		fmt.Printf("    GroupEnd: %+v\n", args.GroupEnd)
+		fmt.Printf("    GroupMaxLines: %d\n", args.GroupMaxLines)
+		fmt.Printf("    Waterfall: %t\n", args.Waterfall)
+		fmt.Printf("    GroupWhere: %+v\n", args.GroupWhere)
+		fmt.Printf("    GroupMinLevel: %s\n", args.GroupMinLevel)
+		fmt.Printf("    GroupSort: %+v\n", args.GroupSort)
+		fmt.Printf("    GroupLimit: %d\n", args.GroupLimit)
+		fmt.Printf("    Stats: %t\n", args.Stats)
+		fmt.Printf("    StatsOnly: %t\n", args.StatsOnly)
+		fmt.Printf("    StatsTop: %d\n", args.StatsTop)
+		fmt.Printf("    Output: %s\n", args.Output)
+		fmt.Printf("    Columns: %+v\n", args.Columns)
+		fmt.Printf("    NoHeader: %t\n", args.NoHeader)
+		fmt.Printf("    TimeFormat: %+v\n", args.TimeFormat)
+		fmt.Printf("    Width: %d\n", args.Width)
+		fmt.Printf("    Color: %s\n", args.Color)
+		fmt.Printf("    Hyperlinks: %s\n", args.Hyperlinks)
+		fmt.Printf("    MessageLayout: %s\n", args.MessageLayout)
+		fmt.Printf("    StackLines: %d\n", args.StackLines)
 	}
 
 	return args, nil
@@ -76,23 +209,260 @@ func parseAllFieldsArg() bool {
 	return allFields != nil && *allFields
 }
 
-// parseGroupByArg parses the --group-by flag into an ordered list of field
-// names. The list is treated as one logical grouping key: for each log entry the
-// first present field wins, and entries are grouped by that value so the same id
-// under different field names (e.g. trace.id vs labels.trace.id) groups together.
-func parseGroupByArg() []string {
+// parseGroupByArg parses the --group-by flag into its nesting levels, separated
+// by "/", each an ordered list of field names separated by ",". A level's list
+// is treated as one logical grouping key: for each log entry the first present
+// field wins, and entries are grouped by that value so the same id under
+// different field names (e.g. trace.id vs labels.trace.id) groups together.
+// service.name/trace.id groups by service, and each service's lines by trace.
+func parseGroupByArg() [][]string {
 	if groupByFlag == nil || *groupByFlag == "" {
 		return nil
 	}
 
-	var fields []string
-	for _, field := range strings.Split(*groupByFlag, ",") {
-		field = strings.TrimSpace(field)
-		if field != "" {
-			fields = append(fields, field)
+	var levels [][]string
+	for _, level := range strings.Split(*groupByFlag, "/") {
+		var fields []string
+		for _, field := range strings.Split(level, ",") {
+			field = strings.TrimSpace(field)
+			if field != "" {
+				fields = append(fields, field)
+			}
+		}
+		if len(fields) > 0 {
+			levels = append(levels, fields)
+		}
+	}
+	return levels
+}
+
+// parseGroupStreamArgs parses --group-idle and --group-end, which turn on
+// streaming group mode.
+func parseGroupStreamArgs() (time.Duration, *Condition, error) {
+	var idle time.Duration
+	if groupIdleFlag != nil && *groupIdleFlag != "" {
+		parsed, err := time.ParseDuration(*groupIdleFlag)
+		if err != nil || parsed <= 0 {
+			return 0, nil, fmt.Errorf("invalid group idle timeout %q, must be a positive duration such as 5s", *groupIdleFlag)
+		}
+		idle = parsed
+	}
+
+	var end *Condition
+	if groupEndFlag != nil && *groupEndFlag != "" {
+		condition, err := parseCondition(*groupEndFlag)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid --group-end: %v", err)
+		}
+		end = condition
+	}
+
+	return idle, end, nil
+}
+
+// parseGroupFilterArgs parses --group-where and --group-min-level, which filter
+// --group-by output by group.
+func parseGroupFilterArgs(logLevelToSeverity map[string]int) (*Condition, string, error) {
+	var where *Condition
+	if groupWhereFlag != nil && *groupWhereFlag != "" {
+		condition, err := parseGroupCondition(*groupWhereFlag)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid --group-where: %v", err)
+		}
+		where = condition
+	}
+
+	var minLevel string
+	if groupMinLevelFlag != nil && *groupMinLevelFlag != "" {
+		if logLevelToSeverity[*groupMinLevelFlag] <= 0 {
+			return nil, "", fmt.Errorf("invalid group minimum log level %q, must be one of trace|debug|info|warning|error|fatal|panic", *groupMinLevelFlag)
 		}
+		minLevel = *groupMinLevelFlag
 	}
-	return fields
+
+	return where, minLevel, nil
+}
+
+// parseGroupSortArg parses --group-sort. Sorting needs every group, so it
+// can't be combined with streaming groups, which are printed as they complete.
+func parseGroupSortArg(streaming bool) (*GroupSort, error) {
+	if groupSortFlag == nil || *groupSortFlag == "" {
+		return nil, nil
+	}
+	if streaming {
+		return nil, fmt.Errorf("--group-sort needs the whole input and can't be combined with --group-idle or --group-end")
+	}
+	return parseGroupSort(*groupSortFlag)
+}
+
+// parseGroupLimitArg parses --group-limit, which like --group-sort can't be
+// combined with streaming groups.
+func parseGroupLimitArg(streaming bool) (int, error) {
+	if groupLimitFlag == nil {
+		return 0, nil
+	}
+	if *groupLimitFlag < 0 {
+		return 0, fmt.Errorf("invalid group limit %d, must be 0 or more", *groupLimitFlag)
+	}
+	if streaming && *groupLimitFlag > 0 {
+		return 0, fmt.Errorf("--group-limit needs the whole input and can't be combined with --group-idle or --group-end")
+	}
+	return *groupLimitFlag, nil
+}
+
+// parseStatsArgs parses --stats, --stats-only and --stats-top. --stats-only
+// implies --stats.
+func parseStatsArgs() (stats bool, statsOnly bool, top int, err error) {
+	statsOnly = statsOnlyFlag != nil && *statsOnlyFlag
+	stats = statsOnly || (statsFlag != nil && *statsFlag)
+
+	top = defaultStatsTop
+	if statsTopFlag != nil {
+		top = *statsTopFlag
+	}
+	if top < 0 {
+		return false, false, 0, fmt.Errorf("invalid stats top %d, must be 0 or more", top)
+	}
+
+	return stats, statsOnly, top, nil
+}
+
+// isGroupStreaming reports whether --group-by output is streamed rather than
+// printed at the end of the input.
+func isGroupStreaming(args Args) bool {
+	return args.GroupIdle > 0 || args.GroupEnd != nil
+}
+
+func parseOutputArg() (string, error) {
+	if outputFlag == nil || *outputFlag == "" {
+		return outputText, nil
+	}
+
+	switch *outputFlag {
+	case outputText, outputHTML, outputCSV, outputTSV:
+		return *outputFlag, nil
+	default:
+		return "", fmt.Errorf("invalid output format %q, must be one of text|html|csv|tsv", *outputFlag)
+	}
+}
+
+func parseColorArg() (string, error) {
+	if colorFlag == nil || *colorFlag == "" {
+		return colorAuto, nil
+	}
+
+	switch *colorFlag {
+	case colorAuto, colorAlways, colorNever:
+		return *colorFlag, nil
+	default:
+		return "", fmt.Errorf("invalid color mode %q, must be one of auto|always|never", *colorFlag)
+	}
+}
+
+// parseMessageLayoutArg parses --message-layout, falling back to the config's
+// MessageLayout for the output mode, and to its default when that isn't valid.
+func parseMessageLayoutArg(config Config, output string) (string, error) {
+	if messageLayoutFlag != nil && *messageLayoutFlag != "" {
+		if !isMessageLayout(*messageLayoutFlag) {
+			return "", fmt.Errorf("invalid message layout %q, must be one of block|escape|raw", *messageLayoutFlag)
+		}
+		return *messageLayoutFlag, nil
+	}
+
+	mode := messageLayoutMode(output, isMultiLine())
+	if layout := config.MessageLayout[mode]; isMessageLayout(layout) {
+		return layout, nil
+	}
+	return defaultMessageLayouts()[mode], nil
+}
+
+func parseHyperlinksArg() (string, error) {
+	if hyperlinksFlag == nil || *hyperlinksFlag == "" {
+		return hyperlinksAuto, nil
+	}
+
+	switch *hyperlinksFlag {
+	case hyperlinksAuto, hyperlinksAlways, hyperlinksNever:
+		return *hyperlinksFlag, nil
+	default:
+		return "", fmt.Errorf("invalid hyperlinks mode %q, must be one of auto|always|never", *hyperlinksFlag)
+	}
+}
+
+// parseThemeArg returns the theme selected by --theme, else PRETTY_LOGRUS_THEME,
+// else the config file's Theme.
+func parseThemeArg(config Config) string {
+	if themeFlag != nil && *themeFlag != "" {
+		return *themeFlag
+	}
+	if name := os.Getenv(ThemeEnvVar); name != "" {
+		return name
+	}
+	return config.Theme
+}
+
+// parseTimeFormatArg parses --time-format, falling back to the config's
+// TimeFormat when the flag isn't given.
+func parseTimeFormatArg(config Config) (*TimeFormat, error) {
+	spec := config.TimeFormat
+	if timeFormatFlag != nil && *timeFormatFlag != "" {
+		spec = *timeFormatFlag
+	}
+	return parseTimeFormat(spec, config.TimeDeltaThreshold)
+}
+
+const widthAuto = "auto"
+
+// parseWidthArg parses --width into the column to wrap lines at, where 0 means
+// no wrapping. auto detects the terminal width and doesn't wrap when stdout is
+// redirected.
+func parseWidthArg() (int, error) {
+	if widthFlag == nil || *widthFlag == "" || *widthFlag == widthAuto {
+		return detectTerminalWidth(), nil
+	}
+
+	width, err := strconv.Atoi(*widthFlag)
+	if err != nil || width < 0 {
+		return 0, fmt.Errorf("invalid width %q, must be auto, 0 or a positive number of columns", *widthFlag)
+	}
+	return width, nil
+}
+
+// parseStackLinesArg parses --stack-lines, falling back to the config's
+// StackTraceLines. 0 means stack traces aren't collapsed.
+func parseStackLinesArg(config Config) (int, error) {
+	if stackLinesFlag == nil || *stackLinesFlag == "" {
+		if config.StackTraceLines < 0 {
+			return 0, nil
+		}
+		return config.StackTraceLines, nil
+	}
+
+	lines, err := strconv.Atoi(*stackLinesFlag)
+	if err != nil || lines < 0 {
+		return 0, fmt.Errorf("invalid stack lines %q, must be 0 or a positive number of lines", *stackLinesFlag)
+	}
+	return lines, nil
+}
+
+// defaultColumns are the --output csv|tsv columns used when --columns is not set.
+var defaultColumns = []string{csvColumnTime, csvColumnLevel, csvColumnPod, csvColumnMessage, AnyField}
+
+// parseColumnsArg parses the --columns flag into an ordered list of column
+// names, falling back to defaultColumns.
+func parseColumnsArg() []string {
+	if columnsFlag == nil || *columnsFlag == "" {
+		return defaultColumns
+	}
+
+	var columns []string
+	for _, column := range strings.Split(*columnsFlag, ",") {
+		column = strings.TrimSpace(column)
+		if column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
 }
 
 func parseLogLevel(logLevelToSeverity map[string]int) (string, error) {
@@ -128,20 +498,6 @@ func parseMaxLogLevel(logLevelToSeverity map[string]int) (string, error) {
 	return "", nil
 }
 
-func parseHighlightKey() string {
-	if highlightKey != nil {
-		return *highlightKey
-	}
-	return ""
-}
-
-func parseHighlightValue() string {
-	if highlightValue != nil {
-		return *highlightValue
-	}
-	return ""
-}
-
 func parseFieldsArg() map[string]struct{} {
 	if fieldsFilter != nil && *fieldsFilter != "" {
 		includedFields := make(map[string]struct{})
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+)
+
+// --color modes.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// colorEnabled decides whether output should be colored. --color always and
+// never are final. In auto mode FORCE_COLOR and CLICOLOR_FORCE turn colors on,
+// NO_COLOR (https://no-color.org) and TERM=dumb turn them off, and otherwise
+// colors are used only when stdout is a terminal.
+func colorEnabled(mode string, getenv func(string) string, isTerminal bool) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+
+	if isEnvFlagSet(getenv("FORCE_COLOR")) || isEnvFlagSet(getenv("CLICOLOR_FORCE")) {
+		return true
+	}
+	if getenv("NO_COLOR") != "" || getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal
+}
+
+// isEnvFlagSet reports whether a boolean-ish environment variable is switched on.
+// FORCE_COLOR=0 and FORCE_COLOR=false are commonly used to mean off.
+func isEnvFlagSet(value string) bool {
+	return value != "" && value != "0" && value != "false"
+}
+
+// applyColorPolicy sets the process-wide color policy for --color. Every color
+// must be created with newColor afterwards to respect it.
+func applyColorPolicy(mode string) {
+	color.NoColor = !colorEnabled(mode, os.Getenv, stdoutIsTerminal())
+	terminalColorDepth = detectColorDepth(os.Getenv)
+	logDebug("Color output enabled: %t (--color=%s), color depth: %d\n", !color.NoColor, mode, terminalColorDepth)
+}
+
+// newColor returns a color that follows the color policy. color.New on its own
+// disables itself whenever NO_COLOR is set, which would defeat --color always
+// and FORCE_COLOR, so the policy is applied to each color explicitly.
+func newColor(attributes ...color.Attribute) *color.Color {
+	c := color.New(attributes...)
+	if color.NoColor {
+		c.DisableColor()
+	} else {
+		c.EnableColor()
+	}
+	return c
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestColorEnabled(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		env        map[string]string
+		isTerminal bool
+		want       bool
+	}{
+		{"auto on a terminal", colorAuto, nil, true, true},
+		{"auto when piped", colorAuto, nil, false, false},
+		{"always when piped", colorAlways, nil, false, true},
+		{"never on a terminal", colorNever, nil, true, false},
+		{"NO_COLOR on a terminal", colorAuto, map[string]string{"NO_COLOR": "1"}, true, false},
+		{"TERM=dumb on a terminal", colorAuto, map[string]string{"TERM": "dumb"}, true, false},
+		{"FORCE_COLOR when piped", colorAuto, map[string]string{"FORCE_COLOR": "1"}, false, true},
+		{"FORCE_COLOR=0 when piped", colorAuto, map[string]string{"FORCE_COLOR": "0"}, false, false},
+		{"CLICOLOR_FORCE beats NO_COLOR", colorAuto, map[string]string{"CLICOLOR_FORCE": "1", "NO_COLOR": "1"}, false, true},
+		{"never beats FORCE_COLOR", colorNever, map[string]string{"FORCE_COLOR": "1"}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			if got := colorEnabled(tt.mode, getenv, tt.isTerminal); got != tt.want {
+				t.Errorf("colorEnabled() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewColorFollowsPolicy(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	t.Setenv("NO_COLOR", "1")
+
+	color.NoColor = false
+	if got := newColor(color.FgRed).Sprint("x"); got != "\x1b[31mx\x1b[0m" {
+		t.Errorf("forced color = %q, want colored output despite NO_COLOR", got)
+	}
+
+	color.NoColor = true
+	if got := newColor(color.FgRed).Sprint("x"); got != "x" {
+		t.Errorf("disabled color = %q, want plain text", got)
+	}
+}
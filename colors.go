@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// colorDepth is how many colors the terminal can display. Colors a Style asks
+// for beyond that are downgraded to the closest color the terminal has.
+type colorDepth int
+
+const (
+	colorDepthBasic colorDepth = iota // the 16 ANSI colors
+	colorDepth256
+	colorDepthTrueColor
+)
+
+// terminalColorDepth is set from the environment by applyColorPolicy.
+var terminalColorDepth = colorDepthBasic
+
+// detectColorDepth guesses the terminal's color support from COLORTERM and TERM,
+// the same variables terminals use to advertise it.
+func detectColorDepth(getenv func(string) string) colorDepth {
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return colorDepthTrueColor
+	}
+
+	term := strings.ToLower(getenv("TERM"))
+	switch {
+	case strings.Contains(term, "truecolor"), strings.Contains(term, "24bit"), strings.Contains(term, "direct"):
+		return colorDepthTrueColor
+	case strings.Contains(term, "256color"):
+		return colorDepth256
+	}
+	return colorDepthBasic
+}
+
+type colorKind int
+
+const (
+	colorKindNamed colorKind = iota // one of the colorCodes names, e.g. fgRed
+	colorKindIndex                  // a 256-color palette index, e.g. 208
+	colorKindRGB                    // #ff8800, #f80 or rgb(255, 136, 0)
+)
+
+// styleColor is a parsed Style.FgColor or Style.BgColor value.
+type styleColor struct {
+	kind  colorKind
+	named color.Attribute
+	index int
+	rgb   [3]int
+}
+
+// parseStyleColor parses a Style color: a name from colorCodes (e.g. fgHiBlue),
+// a 256-color palette index (0-255), a hex color (#ff8800 or #f80) or
+// rgb(255, 136, 0).
+func parseStyleColor(value string) (styleColor, error) {
+	if attr, ok := colorCodes[value]; ok {
+		return styleColor{kind: colorKindNamed, named: attr}, nil
+	}
+
+	trimmed := strings.ToLower(strings.TrimSpace(value))
+
+	if index, err := strconv.Atoi(trimmed); err == nil {
+		if index < 0 || index > 255 {
+			return styleColor{}, fmt.Errorf("color index %d out of range 0-255", index)
+		}
+		return styleColor{kind: colorKindIndex, index: index}, nil
+	}
+
+	if strings.HasPrefix(trimmed, "#") {
+		hex := trimmed[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		n, err := strconv.ParseUint(hex, 16, 32)
+		if len(hex) != 6 || err != nil {
+			return styleColor{}, fmt.Errorf("invalid hex color %q, must be #rrggbb or #rgb", value)
+		}
+		return styleColor{kind: colorKindRGB, rgb: [3]int{int(n >> 16), int(n >> 8 & 0xff), int(n & 0xff)}}, nil
+	}
+
+	if strings.HasPrefix(trimmed, "rgb(") && strings.HasSuffix(trimmed, ")") {
+		parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(trimmed, "rgb("), ")"), ",")
+		if len(parts) == 3 {
+			var rgb [3]int
+			valid := true
+			for i, part := range parts {
+				n, err := strconv.Atoi(strings.TrimSpace(part))
+				if err != nil || n < 0 || n > 255 {
+					valid = false
+					break
+				}
+				rgb[i] = n
+			}
+			if valid {
+				return styleColor{kind: colorKindRGB, rgb: rgb}, nil
+			}
+		}
+		return styleColor{}, fmt.Errorf("invalid color %q, must be rgb(r, g, b) with values 0-255", value)
+	}
+
+	return styleColor{}, fmt.Errorf("unknown color %q, must be a color name such as fgRed, a 256-color index, #rrggbb or rgb(r, g, b)", value)
+}
+
+// attributes returns the SGR parameters for the color, as a foreground or
+// background color, downgraded to what a terminal of the given depth supports.
+// Named colors carry their own fg/bg prefix and are used as they are.
+func (c styleColor) attributes(background bool, depth colorDepth) []color.Attribute {
+	if c.kind == colorKindNamed {
+		return []color.Attribute{c.named}
+	}
+
+	extended := color.Attribute(38)
+	if background {
+		extended = 48
+	}
+
+	if c.kind == colorKindRGB && depth == colorDepthTrueColor {
+		return []color.Attribute{extended, 2, color.Attribute(c.rgb[0]), color.Attribute(c.rgb[1]), color.Attribute(c.rgb[2])}
+	}
+
+	if depth >= colorDepth256 {
+		index := c.index
+		if c.kind == colorKindRGB {
+			index = nearestPaletteIndex(c.rgb)
+		}
+		return []color.Attribute{extended, 5, color.Attribute(index)}
+	}
+
+	rgb := c.rgb
+	if c.kind == colorKindIndex {
+		rgb = paletteRGB(c.index)
+	}
+	return []color.Attribute{basicColorAttribute(nearestBasicColor(rgb), background)}
+}
+
+// rgbValue returns the color as RGB, using the standard xterm values for
+// palette colors. Named colors have no fixed RGB value and return false.
+func (c styleColor) rgbValue() ([3]int, bool) {
+	switch c.kind {
+	case colorKindRGB:
+		return c.rgb, true
+	case colorKindIndex:
+		return paletteRGB(c.index), true
+	}
+	return [3]int{}, false
+}
+
+// styleColorAttributes returns the SGR parameters for a Style color value, or
+// nil (no color) if the value isn't a valid color.
+func styleColorAttributes(value string, background bool) []color.Attribute {
+	c, err := parseStyleColor(value)
+	if err != nil {
+		logDebug("Ignoring color: %v\n", err)
+		return nil
+	}
+	return c.attributes(background, terminalColorDepth)
+}
+
+// basicColorsRGB are the xterm defaults for the 16 ANSI colors, indexed like
+// the 256-color palette: black, red, green, yellow, blue, magenta, cyan, white,
+// then the bright variants.
+var basicColorsRGB = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// paletteCubeLevels are the channel values of the 6x6x6 color cube at palette
+// indices 16-231.
+var paletteCubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// paletteRGB returns the RGB value of a 256-color palette index.
+func paletteRGB(index int) [3]int {
+	switch {
+	case index < 16:
+		return basicColorsRGB[index]
+	case index < 232:
+		i := index - 16
+		return [3]int{paletteCubeLevels[i/36], paletteCubeLevels[i/6%6], paletteCubeLevels[i%6]}
+	default:
+		gray := 8 + 10*(index-232)
+		return [3]int{gray, gray, gray}
+	}
+}
+
+// nearestPaletteIndex returns the color cube or grayscale index closest to rgb.
+// The first 16 entries are skipped since terminals customise them.
+func nearestPaletteIndex(rgb [3]int) int {
+	best, bestDistance := 16, -1
+	for index := 16; index < 256; index++ {
+		if d := rgbDistance(rgb, paletteRGB(index)); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = index, d
+		}
+	}
+	return best
+}
+
+// nearestBasicColor returns the index (0-15) of the ANSI color closest to rgb.
+func nearestBasicColor(rgb [3]int) int {
+	best, bestDistance := 0, -1
+	for index, candidate := range basicColorsRGB {
+		if d := rgbDistance(rgb, candidate); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = index, d
+		}
+	}
+	return best
+}
+
+func basicColorAttribute(index int, background bool) color.Attribute {
+	attr := color.FgBlack + color.Attribute(index)
+	if index >= 8 {
+		attr = color.FgHiBlack + color.Attribute(index-8)
+	}
+	if background {
+		attr += 10
+	}
+	return attr
+}
+
+func rgbDistance(a, b [3]int) int {
+	dr, dg, db := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dr*dr + dg*dg + db*db
+}
+
+// validateConfigColors checks every style color in the config and describes
+// each one that isn't a valid color, e.g.
+// `LevelStyles.error.FgColor: unknown color "fgOrange" ...`.
+func validateConfigColors(config Config) []string {
+	var problems []string
+
+	checkStyle := func(path string, style *Style) {
+		if style == nil {
+			return
+		}
+		for _, c := range []struct {
+			name  string
+			value *string
+		}{{"FgColor", style.FgColor}, {"BgColor", style.BgColor}} {
+			if c.value == nil {
+				continue
+			}
+			if _, err := parseStyleColor(*c.value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s.%s: %v", path, c.name, err))
+			}
+		}
+	}
+
+	checkStyles := func(path string, styles map[string]Style) {
+		for _, key := range sortedKeys(styles) {
+			style := styles[key]
+			checkStyle(path+"."+key, &style)
+		}
+	}
+
+	checkFieldStyles := func(path string, styles map[string]KeyValueStyle) {
+		for _, key := range sortedKeys(styles) {
+			checkStyle(path+"."+key+".Key", styles[key].Key)
+			checkStyle(path+"."+key+".Value", styles[key].Value)
+		}
+	}
+
+	checkPalette := func(path string, palette []string) {
+		for i, value := range palette {
+			if _, err := parseStyleColor(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s[%d]: %v", path, i, err))
+			}
+		}
+	}
+
+	checkStyles("LevelStyles", config.LevelStyles)
+	checkFieldStyles("FieldStyles", config.FieldStyles)
+	checkStyles("MessageStyles", config.MessageStyles)
+	checkStyles("TimestampStyles", config.TimestampStyles)
+	checkStyles("ErrorStyles", config.ErrorStyles)
+	checkStyles("ExcludedFieldsWarningTextStyles", config.ExcludedFieldsWarningTextStyles)
+	checkPalette("PodPalette", config.PodPalette)
+	checkPalette("HighlightPalette", config.HighlightPalette)
+
+	for i, rule := range config.LineStyles {
+		checkStyle(fmt.Sprintf("LineStyles[%d].Style", i), rule.Style)
+		checkStyle(fmt.Sprintf("LineStyles[%d].MessageStyle", i), rule.MessageStyle)
+	}
+
+	for i, rule := range config.MessageRules {
+		checkStyle(fmt.Sprintf("MessageRules[%d].Style", i), rule.Style)
+		for _, group := range sortedKeys(rule.Groups) {
+			checkStyle(fmt.Sprintf("MessageRules[%d].Groups.%s", i, group), rule.Groups[group])
+		}
+	}
+
+	for _, name := range sortedKeys(config.Themes) {
+		theme := config.Themes[name]
+		path := "Themes." + name
+		checkStyles(path+".LevelStyles", theme.LevelStyles)
+		checkFieldStyles(path+".FieldStyles", theme.FieldStyles)
+		checkStyles(path+".MessageStyles", theme.MessageStyles)
+		checkStyles(path+".TimestampStyles", theme.TimestampStyles)
+		checkStyles(path+".ErrorStyles", theme.ErrorStyles)
+		checkPalette(path+".PodPalette", theme.PodPalette)
+	}
+
+	return problems
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
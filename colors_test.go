@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestParseStyleColor(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    styleColor
+		wantErr bool
+	}{
+		{value: "fgRed", want: styleColor{kind: colorKindNamed, named: color.FgRed}},
+		{value: "208", want: styleColor{kind: colorKindIndex, index: 208}},
+		{value: "#ff8800", want: styleColor{kind: colorKindRGB, rgb: [3]int{255, 136, 0}}},
+		{value: "#F80", want: styleColor{kind: colorKindRGB, rgb: [3]int{255, 136, 0}}},
+		{value: "rgb(255, 136, 0)", want: styleColor{kind: colorKindRGB, rgb: [3]int{255, 136, 0}}},
+		{value: "fgOrange", wantErr: true},
+		{value: "256", wantErr: true},
+		{value: "#ff88", wantErr: true},
+		{value: "rgb(300, 0, 0)", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseStyleColor(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStyleColor(%q) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseStyleColor(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStyleColorAttributes(t *testing.T) {
+	orange := styleColor{kind: colorKindRGB, rgb: [3]int{255, 136, 0}}
+
+	tests := []struct {
+		name       string
+		color      styleColor
+		background bool
+		depth      colorDepth
+		want       []color.Attribute
+	}{
+		{"truecolor foreground", orange, false, colorDepthTrueColor, []color.Attribute{38, 2, 255, 136, 0}},
+		{"truecolor background", orange, true, colorDepthTrueColor, []color.Attribute{48, 2, 255, 136, 0}},
+		{"hex downgraded to the 256 palette", orange, false, colorDepth256, []color.Attribute{38, 5, 208}},
+		{"hex downgraded to a basic color", orange, false, colorDepthBasic, []color.Attribute{color.FgYellow}},
+		{"index kept on a 256-color terminal", styleColor{kind: colorKindIndex, index: 27}, false, colorDepth256, []color.Attribute{38, 5, 27}},
+		{"index downgraded to a basic background", styleColor{kind: colorKindIndex, index: 21}, true, colorDepthBasic, []color.Attribute{color.BgBlue}},
+		{"named colors are used as is", styleColor{kind: colorKindNamed, named: color.FgCyan}, true, colorDepthTrueColor, []color.Attribute{color.FgCyan}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.color.attributes(tt.background, tt.depth)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("attributes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectColorDepth(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want colorDepth
+	}{
+		{map[string]string{"COLORTERM": "truecolor", "TERM": "xterm"}, colorDepthTrueColor},
+		{map[string]string{"TERM": "xterm-256color"}, colorDepth256},
+		{map[string]string{"TERM": "xterm"}, colorDepthBasic},
+		{nil, colorDepthBasic},
+	}
+
+	for _, tt := range tests {
+		getenv := func(key string) string { return tt.env[key] }
+		if got := detectColorDepth(getenv); got != tt.want {
+			t.Errorf("detectColorDepth(%v) = %d, want %d", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestValidateConfigColors(t *testing.T) {
+	config := *newDefaultConfig()
+	if problems := validateConfigColors(config); len(problems) != 0 {
+		t.Fatalf("default config has invalid colors: %v", problems)
+	}
+
+	config.LevelStyles = map[string]Style{"error": {FgColor: strPtr("fgOrange"), BgColor: strPtr("#333")}}
+	config.FieldStyles = map[string]KeyValueStyle{"user": {Value: &Style{BgColor: strPtr("rgb(1,2)")}}}
+
+	problems := validateConfigColors(config)
+	if len(problems) != 2 {
+		t.Fatalf("validateConfigColors() = %v, want 2 problems", problems)
+	}
+	if !strings.HasPrefix(problems[0], "LevelStyles.error.FgColor: unknown color \"fgOrange\"") {
+		t.Errorf("problems[0] = %q", problems[0])
+	}
+	if !strings.HasPrefix(problems[1], "FieldStyles.user.Value.BgColor:") {
+		t.Errorf("problems[1] = %q", problems[1])
+	}
+}
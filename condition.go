@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Condition operators, longest first so ">=" isn't read as ">".
+var conditionOperators = []string{">=", "<=", "!=", "=", ">", "<", "~"}
+
+// conditionClause is one comparison such as "http.status>=500".
+type conditionClause struct {
+	Field    string
+	Operator string
+	Value    string
+	pattern  *regexp.Regexp
+}
+
+// Condition is a set of clauses joined by "&&", e.g.
+// "level>=error && http.status>=500". It matches an entry when every clause does.
+type Condition struct {
+	Source  string
+	clauses []conditionClause
+}
+
+// parseCondition parses a condition. Each clause is <field><op><value> where op
+// is one of = != > >= < <= or ~ (regular expression match). The field can be a
+// data field name or one of level, message, pod and app (the workload name, e.g.
+// booking-api for booking-api-7d8f9b6c5-x2k4p, see AppNames). Levels are compared by
+// severity, so level>=warning matches warning, error, fatal and panic.
+func parseCondition(source string) (*Condition, error) {
+	condition := &Condition{Source: source}
+
+	for _, part := range strings.Split(source, "&&") {
+		clause, err := parseConditionClause(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition %q: %v", source, err)
+		}
+		condition.clauses = append(condition.clauses, clause)
+	}
+
+	return condition, nil
+}
+
+func parseConditionClause(clause string) (conditionClause, error) {
+	for i := 0; i < len(clause); i++ {
+		for _, operator := range conditionOperators {
+			if !strings.HasPrefix(clause[i:], operator) {
+				continue
+			}
+
+			parsed := conditionClause{
+				Field:    strings.TrimSpace(clause[:i]),
+				Operator: operator,
+				Value:    strings.TrimSpace(clause[i+len(operator):]),
+			}
+			if parsed.Field == "" {
+				return conditionClause{}, fmt.Errorf("missing field name before %s", operator)
+			}
+
+			if operator == "~" {
+				pattern, err := regexp.Compile(parsed.Value)
+				if err != nil {
+					return conditionClause{}, err
+				}
+				parsed.pattern = pattern
+			}
+
+			return parsed, nil
+		}
+	}
+
+	return conditionClause{}, fmt.Errorf("%q has no operator, expected one of %s", clause, strings.Join(conditionOperators, " "))
+}
+
+// Matches reports whether the entry satisfies every clause. Entries without the
+// field a clause compares never match it.
+func (c *Condition) Matches(entry *LogEntry, logLevelToSeverity map[string]int) bool {
+	if c == nil {
+		return false
+	}
+	for _, clause := range c.clauses {
+		if !clause.matches(entry, logLevelToSeverity) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c conditionClause) matches(entry *LogEntry, logLevelToSeverity map[string]int) bool {
+	actual, ok := conditionFieldValue(entry, c.Field)
+	if !ok {
+		return false
+	}
+
+	if c.pattern != nil {
+		return c.pattern.MatchString(actual)
+	}
+
+	if c.Field == "level" {
+		actualSeverity, actualKnown := logLevelToSeverity[strings.ToLower(actual)]
+		wantSeverity, wantKnown := logLevelToSeverity[strings.ToLower(c.Value)]
+		if actualKnown && wantKnown {
+			return compareOrdered(actualSeverity-wantSeverity, c.Operator)
+		}
+	}
+
+	actualNumber, actualErr := strconv.ParseFloat(actual, 64)
+	wantNumber, wantErr := strconv.ParseFloat(c.Value, 64)
+	if actualErr == nil && wantErr == nil {
+		switch {
+		case actualNumber < wantNumber:
+			return compareOrdered(-1, c.Operator)
+		case actualNumber > wantNumber:
+			return compareOrdered(1, c.Operator)
+		default:
+			return compareOrdered(0, c.Operator)
+		}
+	}
+
+	switch c.Operator {
+	case "=":
+		return actual == c.Value
+	case "!=":
+		return actual != c.Value
+	}
+
+	// Ordering only makes sense for numbers and levels.
+	return false
+}
+
+// compareOrdered applies operator to the sign of a comparison result.
+func compareOrdered(cmp int, operator string) bool {
+	switch operator {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// conditionFieldValue looks up the value a condition compares: the entry's
+// level, message, pod or app, or a data field.
+func conditionFieldValue(entry *LogEntry, field string) (string, bool) {
+	switch field {
+	case "level":
+		return entry.Level, true
+	case "message", "msg":
+		return entry.Message, true
+	case "pod":
+		return entry.PodID, entry.PodID != ""
+	case "app":
+		app := entryApp(entry)
+		return app, app != ""
+	}
+
+	value, ok := entry.Fields[field]
+	return value, ok
+}
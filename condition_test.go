@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestCondition(t *testing.T) {
+	severity := newDefaultConfig().LogLevelToSeverity
+	entry := &LogEntry{
+		Level:   "error",
+		Message: "upstream timed out",
+		PodID:   "api-1",
+		Fields:  map[string]string{"http.status": "503", "user": "bob"},
+	}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"level>=error", true},
+		{"level>=warning", true},
+		{"level<warning", false},
+		{"level=error", true},
+		{"http.status>=500", true},
+		{"http.status<500", false},
+		{"http.status!=200", true},
+		{"user=bob", true},
+		{"user!=bob", false},
+		{"user>bob", false},
+		{"message~timed out$", true},
+		{"pod=api-1", true},
+		{"app=api", true},
+		{"missing=1", false},
+		{"missing!=1", false},
+		{"level>=error && http.status>=500", true},
+		{"level>=error && user=alice", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			condition, err := parseCondition(tt.condition)
+			if err != nil {
+				t.Fatalf("parseCondition(%q) error = %v", tt.condition, err)
+			}
+			if got := condition.Matches(entry, severity); got != tt.want {
+				t.Errorf("Matches() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConditionErrors(t *testing.T) {
+	for _, condition := range []string{"level", ">=error", "message~("} {
+		if _, err := parseCondition(condition); err == nil {
+			t.Errorf("parseCondition(%q) should fail", condition)
+		}
+	}
+}
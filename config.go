@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -10,6 +13,8 @@ const (
 	ecsMessageField   = "message"
 	ecsLevelField     = "log.level"
 	ecsTimestampField = "@timestamp"
+	ecsErrorField     = "error.message"
+	ecsStackField     = "error.stack_trace"
 )
 
 type Style struct {
@@ -26,11 +31,14 @@ type KeyValueStyle struct {
 }
 
 type KeywordConfig struct {
-	MessageKeywords   []string
-	LevelKeywords     []string
-	TimestampKeywords []string
-	ErrorKeywords     []string
-	FieldKeywords     []string
+	MessageKeywords    []string
+	LevelKeywords      []string
+	TimestampKeywords  []string
+	ErrorKeywords      []string
+	StackKeywords      []string
+	SpanKeywords       []string
+	ParentSpanKeywords []string
+	FieldKeywords      []string
 }
 
 type Config struct {
@@ -38,47 +46,95 @@ type Config struct {
 	FieldStyles                     map[string]KeyValueStyle
 	MessageStyles                   map[string]Style
 	TimestampStyles                 map[string]Style
+	ErrorStyles                     map[string]Style
 	Keywords                        *KeywordConfig
 	ExcludeFields                   []string
 	ExcludedFieldsWarningText       string
 	ExcludedFieldsWarningTextStyles map[string]Style
 	LogLevelToSeverity              map[string]int
+	TimeFormat                      string
+	TimeDeltaThreshold              string
+	FieldOrder                      *FieldOrderConfig
+	Theme                           string
+	Themes                          map[string]Theme
+	PodPalette                      []string
+	LineStyles                      []LineStyleRule
+	MessageRules                    []MessageRule
+	BuiltinMessageRules             map[string]bool
+	HighlightPalette                []string
+	StackTraceLines                 int
+	Links                           map[string]string
+	LinkVariables                   map[string]string
+	FieldFormats                    map[string]string
+	MessageLayout                   map[string]string
+	GroupHeader                     []string
+	AppNames                        []AppNameRule
+	PodLabel                        string
+
+	// fileStyles holds only the styles set in the config file, so a theme can
+	// be layered underneath them.
+	fileStyles Theme
+	// messageRules holds MessageRules followed by the built-in rules turned on
+	// in BuiltinMessageRules.
+	messageRules []MessageRule
+	// fieldFormats holds the parsed FieldFormats.
+	fieldFormats map[string]fieldFormat
 }
 
 func newDefaultConfig() *Config {
 	return &Config{
-		FieldStyles:     DefaultFieldStyles,
-		LevelStyles:     DefaultLevelStyles,
-		MessageStyles:   DefaultMessageStyles,
-		TimestampStyles: DefaultTimestampStyles,
+		// The maps are copied so reading a config file into them doesn't change
+		// the defaults.
+		FieldStyles:     layerStyles(DefaultFieldStyles),
+		LevelStyles:     layerStyles(DefaultLevelStyles),
+		MessageStyles:   layerStyles(DefaultMessageStyles),
+		TimestampStyles: layerStyles(DefaultTimestampStyles),
+		ErrorStyles:     layerStyles(DefaultErrorStyles),
 		Keywords: &KeywordConfig{
-			MessageKeywords:   []string{logrus.FieldKeyMsg, ecsMessageField},
-			LevelKeywords:     []string{logrus.FieldKeyLevel, ecsLevelField},
-			TimestampKeywords: []string{logrus.FieldKeyTime, ecsTimestampField},
-			ErrorKeywords:     []string{logrus.ErrorKey},
-			FieldKeywords:     []string{"labels"},
+			MessageKeywords:    []string{logrus.FieldKeyMsg, ecsMessageField},
+			LevelKeywords:      []string{logrus.FieldKeyLevel, ecsLevelField},
+			TimestampKeywords:  []string{logrus.FieldKeyTime, ecsTimestampField},
+			ErrorKeywords:      []string{logrus.ErrorKey, ecsErrorField},
+			StackKeywords:      []string{"stack", "stacktrace", ecsStackField},
+			SpanKeywords:       []string{"span.id", "span_id"},
+			ParentSpanKeywords: []string{"parent.id", "parent_span_id"},
+			FieldKeywords:      []string{"labels"},
 		},
 		ExcludeFields:                   []string{},
 		ExcludedFieldsWarningText:       "[Some fields excluded]",
-		ExcludedFieldsWarningTextStyles: DefaultExcludedWarningTextStyles,
-		LogLevelToSeverity: map[string]int{
-			"":        -1,
-			"trace":   1,
-			"debug":   2,
-			"info":    3,
-			"warning": 4,
-			"error":   5,
-			"fatal":   6,
-			"panic":   7,
+		ExcludedFieldsWarningTextStyles: layerStyles(DefaultExcludedWarningTextStyles),
+		LogLevelToSeverity:              defaultLogLevelToSeverity(),
+		TimeFormat:                      timeFormatRaw,
+		TimeDeltaThreshold:              "1s",
+		BuiltinMessageRules:             defaultBuiltinMessageRules(),
+		MessageLayout:                   defaultMessageLayouts(),
+		GroupHeader:                     defaultGroupHeader(),
+		PodLabel:                        podLabelPod,
+		FieldOrder: &FieldOrderConfig{
+			Pinned: []string{},
+			Sort:   fieldOrderAlphabetical,
 		},
 	}
 }
 
+func defaultLogLevelToSeverity() map[string]int {
+	return map[string]int{
+		"":        -1,
+		"trace":   1,
+		"debug":   2,
+		"info":    3,
+		"warning": 4,
+		"error":   5,
+		"fatal":   6,
+		"panic":   7,
+	}
+}
+
 func getConfig() *Config {
 	defaultConfig := newDefaultConfig()
 
 	if err := ensureConfigFileExistsIfHomeEnvIsSet(defaultConfig); err != nil {
-		logDebug("Error ensuring config file exists: ", err)
+		logDebug("Error ensuring config file exists: %v\n", err)
 		return defaultConfig
 	}
 
@@ -99,5 +155,46 @@ func getConfig() *Config {
 
 	logDebug("Loaded config file: %+v\n", configFile)
 
+	for _, problem := range validateConfigColors(*configFile) {
+		fmt.Fprintf(os.Stderr, "Invalid color in config file: %s\n", problem)
+	}
+
+	lineStyles, problems := compileLineStyles(configFile.LineStyles)
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "Invalid line style in config file: %s\n", problem)
+	}
+	configFile.LineStyles = lineStyles
+
+	messageRules, problems := compileMessageRules(configFile.MessageRules, configFile.BuiltinMessageRules)
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "Invalid message rule in config file: %s\n", problem)
+	}
+	configFile.MessageRules = messageRules
+	configFile.messageRules = activeMessageRules(messageRules, configFile.BuiltinMessageRules)
+
+	fieldFormats, problems := compileFieldFormats(configFile.FieldFormats)
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "Invalid field format in config file: %s\n", problem)
+	}
+	configFile.fieldFormats = fieldFormats
+
+	for _, problem := range validateMessageLayouts(configFile.MessageLayout) {
+		fmt.Fprintf(os.Stderr, "Invalid message layout in config file: %s\n", problem)
+	}
+
+	appNames, problems := compileAppNameRules(configFile.AppNames)
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "Invalid app name rule in config file: %s\n", problem)
+	}
+	configFile.AppNames = appNames
+
+	for _, problem := range validatePodLabel(configFile.PodLabel) {
+		fmt.Fprintf(os.Stderr, "Invalid pod label in config file: %s\n", problem)
+	}
+
+	for _, problem := range validateGroupHeader(configFile.GroupHeader) {
+		fmt.Fprintf(os.Stderr, "Invalid group header in config file: %s\n", problem)
+	}
+
 	return configFile
 }
@@ -95,6 +95,9 @@ func readConfigFile(defaultConfig Config) (*Config, error) {
 	if err = json.Unmarshal(content, &config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config file: %v", err)
 	}
+	if err = json.Unmarshal(content, &config.fileStyles); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config file: %v", err)
+	}
 
 	if isDebug() {
 		configJson, _ := json.MarshalIndent(config, "", "  ")
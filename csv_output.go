@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Built-in --columns names. Any other column name is looked up as a data field.
+const (
+	csvColumnTime    = "time"
+	csvColumnLevel   = "level"
+	csvColumnPod     = "pod"
+	csvColumnMessage = "message"
+	csvColumnLine    = "line"
+)
+
+// renderCSV writes the entries that pass the active filters as CSV (or TSV).
+// Rows are streamed as they arrive unless the columns contain the * wildcard or
+// --group-by is active; both need the whole input, so those runs buffer and
+// write once the stream closes.
+func renderCSV(ctx context.Context, args Args, config Config, logEntries <-chan *LogEntry, timeFormatter *TimeFormatter) {
+	w := newCSVWriter(os.Stdout, args.Output)
+	batch := len(args.GroupBy) > 0 || slices.Contains(args.Columns, AnyField)
+
+	var buffer []*LogEntry
+	if !batch && !args.NoHeader {
+		writeCSVRow(w, args.Columns)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case logEntry, ok := <-logEntries:
+			if !ok {
+				if batch {
+					writeCSVBatch(w, args, config, buffer, timeFormatter)
+				}
+				return
+			}
+
+			if !shouldShowLogLine(args, config, logEntry) {
+				if isDebug() {
+					fmt.Printf("Not showing log entry %d\n", logEntry.LineNumber)
+				}
+				continue
+			}
+
+			if batch {
+				buffer = append(buffer, logEntry)
+				continue
+			}
+
+			writeCSVRow(w, csvRow(args, config, args.Columns, logEntry, timeFormatter))
+		}
+	}
+}
+
+func newCSVWriter(out io.Writer, output string) *csv.Writer {
+	w := csv.NewWriter(out)
+	if output == outputTSV {
+		w.Comma = '\t'
+	}
+	return w
+}
+
+// writeCSVBatch writes a buffered run: the * column is expanded against every
+// entry seen, and with --group-by rows are written group by group.
+func writeCSVBatch(w *csv.Writer, args Args, config Config, entries []*LogEntry, timeFormatter *TimeFormatter) {
+	columns := expandColumns(args, config, args.Columns, entries)
+
+	if !args.NoHeader {
+		writeCSVRow(w, columns)
+	}
+
+	if len(args.GroupBy) > 0 {
+		groups, ungrouped, _ := groupAndFilterEntries(args, config, entries)
+		entries = flattenGroups(groups, ungrouped, nil)
+	}
+
+	for _, entry := range entries {
+		writeCSVRow(w, csvRow(args, config, columns, entry, timeFormatter))
+	}
+}
+
+func writeCSVRow(w *csv.Writer, row []string) {
+	if err := w.Write(row); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing CSV output: %v\n", err)
+		return
+	}
+	w.Flush()
+}
+
+// expandColumns replaces the * column with the sorted union of the data field
+// names seen across entries, leaving out fields already listed explicitly and
+// fields hidden by --fields, --except or ExcludeFields.
+func expandColumns(args Args, config Config, columns []string, entries []*LogEntry) []string {
+	if !slices.Contains(columns, AnyField) {
+		return columns
+	}
+
+	explicit := make(map[string]struct{}, len(columns))
+	for _, column := range columns {
+		explicit[column] = struct{}{}
+	}
+
+	seen := make(map[string]struct{})
+	var names []string
+	for _, entry := range entries {
+		fieldNames, _ := selectFields(args, config, entry)
+		for _, name := range fieldNames {
+			if _, dup := seen[name]; dup {
+				continue
+			}
+			if _, dup := explicit[name]; dup {
+				continue
+			}
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	// Columns are shared by every row, so source order can't apply; pinned
+	// fields still come first.
+	orderFieldNames(names, config.FieldOrder, nil)
+
+	var expanded []string
+	for _, column := range columns {
+		if column == AnyField {
+			expanded = append(expanded, names...)
+			continue
+		}
+		expanded = append(expanded, column)
+	}
+	return expanded
+}
+
+// csvRow returns the cell values for entry. Missing fields become empty cells.
+// Unparsed lines carry their raw content in the message column. The time column
+// follows --time-format, and the message the csv MessageLayout.
+func csvRow(args Args, config Config, columns []string, entry *LogEntry, timeFormatter *TimeFormatter) []string {
+	row := make([]string, len(columns))
+
+	for i, column := range columns {
+		switch column {
+		case csvColumnTime:
+			row[i], _ = timeFormatter.Format(entry)
+		case csvColumnLevel:
+			row[i] = entry.Level
+		case csvColumnPod:
+			row[i] = entry.PodID
+		case csvColumnLine:
+			row[i] = strconv.Itoa(entry.LineNumber)
+		case csvColumnMessage:
+			if entry.IsParsed {
+				row[i] = strings.Join(splitMessage(fmtMessage(args.Truncate, entry.Message), args.MessageLayout), "\n")
+			} else {
+				row[i] = strings.TrimRight(string(entry.OriginalLogLine), "\r\n")
+			}
+		default:
+			if value, ok := entry.Fields[column]; ok {
+				row[i] = fmtValue(args.Truncate, config.fieldFormats, column, value)
+			}
+		}
+	}
+
+	return row
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandColumns(t *testing.T) {
+	config := *newDefaultConfig()
+	entries := []*LogEntry{
+		{Fields: map[string]string{"trace.id": "a", "http.status": "200"}},
+		{Fields: map[string]string{"user": "bob", "trace.id": "b"}},
+	}
+
+	t.Run("expands * into the sorted union of field names not listed explicitly", func(t *testing.T) {
+		got := expandColumns(Args{}, config, []string{"time", "trace.id", "*"}, entries)
+		want := []string{"time", "trace.id", "http.status", "user"}
+
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("expandColumns() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("leaves out fields hidden by --except", func(t *testing.T) {
+		args := Args{ExcludedFields: map[string]struct{}{"user": {}}}
+		got := expandColumns(args, config, []string{"*"}, entries)
+		want := []string{"http.status", "trace.id"}
+
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("expandColumns() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("returns columns unchanged without a wildcard", func(t *testing.T) {
+		got := expandColumns(Args{}, config, []string{"level", "user"}, entries)
+		if strings.Join(got, ",") != "level,user" {
+			t.Errorf("expandColumns() = %v, want [level user]", got)
+		}
+	})
+}
+
+func TestCSVRow(t *testing.T) {
+	columns := []string{"time", "level", "pod", "message", "line", "trace.id", "missing"}
+	config := *newDefaultConfig()
+
+	t.Run("maps built-in columns and fields, leaving missing fields empty", func(t *testing.T) {
+		entry := &LogEntry{LineNumber: 7, PodID: "api-1", Time: "t", Level: "info", Message: "hello", Fields: map[string]string{"trace.id": "abc"}, IsParsed: true}
+
+		got := csvRow(Args{}, config, columns, entry, nil)
+		want := []string{"t", "info", "api-1", "hello", "7", "abc", ""}
+
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("csvRow() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("puts the raw line in the message column for unparsed lines", func(t *testing.T) {
+		entry := &LogEntry{LineNumber: 1, OriginalLogLine: []byte("plain text\n")}
+
+		got := csvRow(Args{}, config, []string{"message"}, entry, nil)
+		if got[0] != "plain text" {
+			t.Errorf("message cell = %q, want %q", got[0], "plain text")
+		}
+	})
+}
+
+func TestWriteCSVBatch(t *testing.T) {
+	config := *newDefaultConfig()
+	entries := []*LogEntry{
+		{LineNumber: 1, Level: "info", Message: `say "hi", then go`, Fields: map[string]string{"a": "1"}, IsParsed: true},
+		{LineNumber: 2, Level: "error", Message: "tab\there", Fields: map[string]string{"b": "2"}, IsParsed: true},
+	}
+
+	t.Run("quotes csv values and writes a header", func(t *testing.T) {
+		var out strings.Builder
+		args := Args{Output: outputCSV, Columns: []string{"level", "message", "*"}}
+
+		writeCSVBatch(newCSVWriter(&out, args.Output), args, config, entries, nil)
+
+		want := "level,message,a,b\n" +
+			`info,"say ""hi"", then go",1,` + "\n" +
+			"error,tab\there,,2\n"
+		if out.String() != want {
+			t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+		}
+	})
+
+	t.Run("separates tsv cells with tabs and omits the header with --no-header", func(t *testing.T) {
+		var out strings.Builder
+		args := Args{Output: outputTSV, Columns: []string{"level", "message"}, NoHeader: true}
+
+		writeCSVBatch(newCSVWriter(&out, args.Output), args, config, entries, nil)
+
+		want := "info\t\"say \"\"hi\"\", then go\"\n" +
+			"error\t\"tab\there\"\n"
+		if out.String() != want {
+			t.Errorf("output =\n%q\nwant\n%q", out.String(), want)
+		}
+	})
+}
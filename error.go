@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// stackFramePattern matches the source locations in stack trace lines, e.g.
+// /app/main.go:42, Booking.java:118 or Python's File "app.py", line 7. The
+// extension must start with a letter, so addresses like 10.0.0.1:8080 don't
+// count.
+var stackFramePattern = regexp.MustCompile(`[\w.\-/\\]*\.[a-zA-Z]\w*:\d+(?::\d+)?|File "[^"]+", line \d+`)
+
+// takeErrorFields removes the entry's error and stack fields from fieldNames,
+// reporting which of them were there. Those are shown in the error slot
+// instead, so --fields and --except decide whether it's shown.
+func takeErrorFields(fieldNames []string, logEntry *LogEntry) (rest []string, showError, showStack bool) {
+	rest = make([]string, 0, len(fieldNames))
+	for _, fieldName := range fieldNames {
+		switch {
+		case logEntry.ErrorKey != "" && fieldName == logEntry.ErrorKey:
+			showError = true
+		case logEntry.StackKey != "" && fieldName == logEntry.StackKey:
+			showStack = true
+		default:
+			rest = append(rest, fieldName)
+		}
+	}
+	return rest, showError, showStack
+}
+
+// errorParts returns what the error slot shows: the first line of the error,
+// and the stack trace lines. Errors formatted with their stack trace (e.g.
+// %+v of a pkg/errors error) contribute the lines after the first to the stack
+// trace, followed by the stack field's.
+func errorParts(args Args, logEntry *LogEntry, showError, showStack bool) (errorText string, stack []string) {
+	if showError {
+		lines := splitMessage(fmtValue(args.Truncate, nil, logEntry.ErrorKey, logEntry.Error), messageLayoutBlock)
+		errorText, stack = lines[0], lines[1:]
+	}
+	if showStack {
+		if value := fmtValue(args.Truncate, nil, logEntry.StackKey, logEntry.Stack); strings.TrimSpace(value) != "" {
+			stack = append(stack, splitMessage(value, messageLayoutBlock)...)
+		}
+	}
+	return errorText, stack
+}
+
+// collapseStack keeps the first limit lines of a stack trace, returning how
+// many were left out. A limit of 0 keeps every line.
+func collapseStack(stack []string, limit int) (shown []string, hidden int) {
+	if limit <= 0 || len(stack) <= limit {
+		return stack, 0
+	}
+	return stack[:limit], len(stack) - limit
+}
+
+// stackFrameSpans returns the file:line frames in a stack trace line, in the
+// frame error style.
+func stackFrameSpans(config Config, line string) []textSpan {
+	style := resolveErrorStyle(ErrorFrameStylesKey, config.ErrorStyles)
+
+	var spans []textSpan
+	for _, match := range stackFramePattern.FindAllStringIndex(line, -1) {
+		spans = append(spans, textSpan{Start: match[0], End: match[1], Style: style})
+	}
+	return spans
+}
+
+// formatError renders the error slot, e.g. "error: connection refused", with
+// --highlight-value and --where matches highlighted in the error.
+func formatError(args Args, config Config, logEntry *LogEntry, errorText string) string {
+	style := resolveErrorStyle(DefaultStylesKey, config.ErrorStyles)
+	return styleString(style, logEntry.ErrorKey+": ") +
+		renderSpans(errorText, style, valueHighlightSpans(args, logEntry.ErrorKey, errorText), styleString)
+}
+
+// formatStackBlock renders stack trace lines as an indented block with the
+// frames highlighted, collapsed to --stack-lines.
+func formatStackBlock(args Args, config Config, stack []string) string {
+	style := resolveErrorStyle(ErrorStackStylesKey, config.ErrorStyles)
+	shown, hidden := collapseStack(stack, args.StackLines)
+
+	lines := make([]string, 0, len(shown)+1)
+	for _, line := range shown {
+		lines = append(lines, softWrap(messageBlockIndent, []wrapSegment{{Text: renderSpans(line, style, stackFrameSpans(config, line), styleString)}}, args.Width))
+	}
+	if hidden > 0 {
+		noun := "lines"
+		if hidden == 1 {
+			noun = "line"
+		}
+		lines = append(lines, messageBlockIndent+styleString(style, fmt.Sprintf("… %d more %s", hidden, noun)))
+	}
+	return strings.Join(lines, "\n")
+}
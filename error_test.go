@@ -0,0 +1,92 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTakeErrorFields(t *testing.T) {
+	entry := &LogEntry{ErrorKey: "error", StackKey: "stack"}
+
+	rest, showError, showStack := takeErrorFields([]string{"a", "error", "b"}, entry)
+
+	if !reflect.DeepEqual(rest, []string{"a", "b"}) {
+		t.Errorf("rest = %q, want [a b]", rest)
+	}
+	if !showError || showStack {
+		t.Errorf("showError, showStack = %t, %t, want true, false", showError, showStack)
+	}
+}
+
+func TestErrorParts(t *testing.T) {
+	entry := &LogEntry{
+		Error:    "book: timeout\nmain.book\n\tbooking.go:42",
+		ErrorKey: "error",
+		Stack:    "main.main\n\tmain.go:12\n",
+		StackKey: "stack",
+	}
+
+	errorText, stack := errorParts(Args{}, entry, true, true)
+
+	if errorText != "book: timeout" {
+		t.Errorf("errorText = %q, want %q", errorText, "book: timeout")
+	}
+	want := []string{"main.book", "    booking.go:42", "main.main", "    main.go:12"}
+	if !reflect.DeepEqual(stack, want) {
+		t.Errorf("stack = %q, want %q", stack, want)
+	}
+
+	t.Run("leaves out fields that aren't shown", func(t *testing.T) {
+		errorText, stack := errorParts(Args{}, entry, false, true)
+		if errorText != "" || len(stack) != 2 {
+			t.Errorf("errorParts() = %q, %q, want only the stack field", errorText, stack)
+		}
+	})
+}
+
+func TestCollapseStack(t *testing.T) {
+	stack := []string{"1", "2", "3"}
+
+	if shown, hidden := collapseStack(stack, 0); len(shown) != 3 || hidden != 0 {
+		t.Errorf("collapseStack(0) = %q, %d, want every line", shown, hidden)
+	}
+	if shown, hidden := collapseStack(stack, 2); !reflect.DeepEqual(shown, []string{"1", "2"}) || hidden != 1 {
+		t.Errorf("collapseStack(2) = %q, %d, want [1 2], 1", shown, hidden)
+	}
+}
+
+func TestStackFrameSpans(t *testing.T) {
+	config := *newDefaultConfig()
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"\t/app/booking.go:42 +0x1d", []string{"/app/booking.go:42"}},
+		{"at com.example.Booking.book(Booking.java:118)", []string{"Booking.java:118"}},
+		{`  File "app.py", line 7, in main`, []string{`File "app.py", line 7`}},
+		{"dial tcp 10.0.0.1:5432", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, span := range stackFrameSpans(config, tt.line) {
+			got = append(got, tt.line[span.Start:span.End])
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("stackFrameSpans(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestFormatStackBlock(t *testing.T) {
+	config := *newDefaultConfig()
+	args := Args{StackLines: 1}
+
+	got := stripANSI(formatStackBlock(args, config, []string{"main.main", "    main.go:12"}))
+	want := strings.Join([]string{"    main.main", "    … 1 more line"}, "\n")
+	if got != want {
+		t.Errorf("formatStackBlock() = %q, want %q", got, want)
+	}
+}
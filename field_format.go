@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatters for FieldFormats. Some take a unit, e.g. duration(ms).
+const (
+	fieldFormatDuration = "duration"
+	fieldFormatBytes    = "bytes"
+	fieldFormatEpoch    = "epoch"
+	fieldFormatPercent  = "percent"
+	fieldFormatJSON     = "json"
+)
+
+// fieldFormatUnits lists the units each formatter accepts. The first is the
+// default when no unit is given; an empty default means it's guessed from the
+// value.
+var fieldFormatUnits = map[string][]string{
+	fieldFormatDuration: {"ns", "us", "ms", "s"},
+	fieldFormatBytes:    nil,
+	fieldFormatEpoch:    {"", "s", "ms", "us", "ns"},
+	fieldFormatPercent:  nil,
+	fieldFormatJSON:     nil,
+}
+
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+}
+
+var fieldFormatSpec = regexp.MustCompile(`^\s*(\w+)\s*(?:\(\s*(\w*)\s*\))?\s*$`)
+
+// fieldFormat is a parsed FieldFormats value such as "duration(ms)".
+type fieldFormat struct {
+	Name string
+	Unit string
+}
+
+func parseFieldFormat(spec string) (fieldFormat, error) {
+	match := fieldFormatSpec.FindStringSubmatch(spec)
+	if match == nil {
+		return fieldFormat{}, fmt.Errorf("invalid format %q, expected a name such as bytes or duration(ms)", spec)
+	}
+
+	format := fieldFormat{Name: match[1], Unit: match[2]}
+	units, ok := fieldFormatUnits[format.Name]
+	if !ok {
+		return fieldFormat{}, fmt.Errorf("unknown format %q, must be one of duration|bytes|epoch|percent|json", format.Name)
+	}
+
+	if format.Unit == "" {
+		if len(units) > 0 {
+			format.Unit = units[0]
+		}
+		return format, nil
+	}
+	for _, unit := range units {
+		if unit != "" && unit == format.Unit {
+			return format, nil
+		}
+	}
+	return fieldFormat{}, fmt.Errorf("format %q doesn't take the unit %q", format.Name, format.Unit)
+}
+
+// compileFieldFormats parses the FieldFormats values once, when the config is
+// loaded. Values that can't be parsed are left out and described in problems.
+func compileFieldFormats(formats map[string]string) (compiled map[string]fieldFormat, problems []string) {
+	compiled = make(map[string]fieldFormat, len(formats))
+	for _, field := range sortedKeys(formats) {
+		format, err := parseFieldFormat(formats[field])
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("FieldFormats.%s: %v", field, err))
+			continue
+		}
+		compiled[field] = format
+	}
+	return compiled, problems
+}
+
+// findFieldFormat returns the formatter for a field: the one for its exact
+// name, else the first wildcard key (in sorted order) matching it.
+func findFieldFormat(formats map[string]fieldFormat, fieldName string) (fieldFormat, bool) {
+	if format, ok := formats[fieldName]; ok {
+		return format, true
+	}
+	for _, key := range sortedKeys(formats) {
+		if strings.Contains(key, "*") && matchesHighlight(fieldName, key) {
+			return formats[key], true
+		}
+	}
+	return fieldFormat{}, false
+}
+
+// applyFieldFormat formats a field's value for display with its FieldFormats
+// formatter. Values the formatter can't read are returned as they are.
+func applyFieldFormat(formats map[string]fieldFormat, fieldName, value string) string {
+	format, ok := findFieldFormat(formats, fieldName)
+	if !ok {
+		return value
+	}
+
+	if formatted, ok := format.apply(value); ok {
+		return formatted
+	}
+	logDebug("Field %s: %q can't be formatted as %s\n", fieldName, value, format.Name)
+	return value
+}
+
+func (f fieldFormat) apply(value string) (string, bool) {
+	if f.Name == fieldFormatJSON {
+		return formatJSON(value)
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return "", false
+	}
+
+	switch f.Name {
+	case fieldFormatDuration:
+		return formatDuration(time.Duration(number * float64(durationUnits[f.Unit]))), true
+	case fieldFormatBytes:
+		return formatBytes(number), true
+	case fieldFormatEpoch:
+		return formatEpoch(number, f.Unit), true
+	case fieldFormatPercent:
+		return trimFloat(number*100, 1) + "%", true
+	}
+	return "", false
+}
+
+// formatDuration rounds a duration to a readable precision, e.g. 1.234s or
+// 12.346ms rather than 1.234567891s.
+func formatDuration(d time.Duration) string {
+	abs := d
+	if abs < 0 {
+		abs = -abs
+	}
+
+	switch {
+	case abs >= time.Minute:
+		return d.Round(time.Second).String()
+	case abs >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case abs >= time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	}
+	return d.String()
+}
+
+var byteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+
+// formatBytes formats a byte count in binary units, e.g. 1.5 KiB.
+func formatBytes(bytes float64) string {
+	unit := 0
+	for math.Abs(bytes) >= 1024 && unit < len(byteUnits)-1 {
+		bytes /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return trimFloat(bytes, 0) + " " + byteUnits[unit]
+	}
+	return trimFloat(bytes, 1) + " " + byteUnits[unit]
+}
+
+// formatEpoch formats a Unix timestamp in UTC. Without a unit it's guessed
+// from the magnitude: seconds, milliseconds, microseconds or nanoseconds.
+func formatEpoch(epoch float64, unit string) string {
+	if unit == "" {
+		switch abs := math.Abs(epoch); {
+		case abs >= 1e17:
+			unit = "ns"
+		case abs >= 1e14:
+			unit = "us"
+		case abs >= 1e11:
+			unit = "ms"
+		default:
+			unit = "s"
+		}
+	}
+
+	nanos := epoch * float64(durationUnits[unit])
+	return time.Unix(0, int64(nanos)).UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// formatJSON re-encodes a JSON value compactly with its keys sorted. It sees
+// field values as read, so it applies to JSON documents logged as strings:
+// objects logged as objects have been flattened into dotted fields by then.
+func formatJSON(value string) (string, bool) {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		return "", false
+	}
+
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(decoded); err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(b.String(), "\n"), true
+}
+
+// trimFloat formats f with at most decimals decimals, dropping trailing zeros.
+func trimFloat(f float64, decimals int) string {
+	s := strconv.FormatFloat(f, 'f', decimals, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
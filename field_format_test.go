@@ -0,0 +1,86 @@
+package main
+
+import "testing"
+
+func TestApplyFieldFormat(t *testing.T) {
+	formats, problems := compileFieldFormats(map[string]string{
+		"duration_ns":    "duration",
+		"latency_ms":     "duration(ms)",
+		"*_bytes":        "bytes",
+		"response.bytes": "bytes",
+		"created_at":     "epoch",
+		"updated_at":     "epoch(s)",
+		"ratio":          "percent",
+		"body":           "json",
+	})
+	if len(problems) > 0 {
+		t.Fatalf("compileFieldFormats() problems = %v", problems)
+	}
+
+	tests := []struct {
+		field string
+		value string
+		want  string
+	}{
+		{"duration_ns", "1234567891", "1.235s"},
+		{"duration_ns", "1.5e+06", "1.5ms"},
+		{"latency_ms", "250", "250ms"},
+		{"latency_ms", "90000", "1m30s"},
+		{"response.bytes", "1536", "1.5 KiB"},
+		{"request_bytes", "512", "512 B"},
+		{"response.bytes", "5368709120", "5 GiB"},
+		{"created_at", "1716812141974", "2024-05-27T12:15:41.974Z"},
+		{"created_at", "1.716812141974e+12", "2024-05-27T12:15:41.974Z"},
+		{"created_at", "1716812141", "2024-05-27T12:15:41.000Z"},
+		{"updated_at", "1716812141", "2024-05-27T12:15:41.000Z"},
+		{"ratio", "0.1534", "15.3%"},
+		{"ratio", "1", "100%"},
+		{"body", `{"b": [1, 2], "a": "<x>"}`, `{"a":"<x>","b":[1,2]}`},
+		{"duration_ns", "n/a", "n/a"},
+		{"body", "not json", "not json"},
+		{"other", "1536", "1536"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field+"="+tt.value, func(t *testing.T) {
+			if got := applyFieldFormat(formats, tt.field, tt.value); got != tt.want {
+				t.Errorf("applyFieldFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFieldFormat(t *testing.T) {
+	valid := []string{"duration", "duration(us)", "bytes", "epoch", "epoch(ms)", "percent", "json", " duration ( s ) "}
+	for _, spec := range valid {
+		if _, err := parseFieldFormat(spec); err != nil {
+			t.Errorf("parseFieldFormat(%q) failed: %v", spec, err)
+		}
+	}
+
+	invalid := []string{"", "size", "bytes(kb)", "duration(h)", "duration(ms"}
+	for _, spec := range invalid {
+		if _, err := parseFieldFormat(spec); err == nil {
+			t.Errorf("parseFieldFormat(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestCompileFieldFormatsReportsProblems(t *testing.T) {
+	formats, problems := compileFieldFormats(map[string]string{"a": "bytes", "b": "size", "c": "duration(h)"})
+
+	if len(problems) != 2 {
+		t.Errorf("problems = %v, want 2", problems)
+	}
+	if _, ok := formats["a"]; !ok || len(formats) != 1 {
+		t.Errorf("compiled formats = %v, want only a", formats)
+	}
+}
+
+func TestFmtValueFormatsBeforeTruncating(t *testing.T) {
+	truncate := &Truncate{FieldName: "size", NumChars: 3}
+	formats := map[string]fieldFormat{"size": {Name: fieldFormatBytes}}
+	if got := fmtValue(truncate, formats, "size", "2048"); got != "2 K" {
+		t.Errorf("fmtValue() = %q, want %q", got, "2 K")
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// FieldOrder sort modes for the fields that aren't pinned.
+const (
+	fieldOrderAlphabetical = "alphabetical"
+	fieldOrderSource       = "source"
+)
+
+// FieldOrderConfig decides the order data fields are printed in.
+type FieldOrderConfig struct {
+	// Pinned fields are printed first, in the order listed. Entries may have
+	// leading and/or trailing wildcards, e.g. "trace.*".
+	Pinned []string
+	// Sort orders the remaining fields: "alphabetical" (default) or "source"
+	// for the order they appear in the JSON log line.
+	Sort string
+}
+
+func (o *FieldOrderConfig) sortsBySource() bool {
+	return o != nil && o.Sort == fieldOrderSource
+}
+
+// orderFieldNames sorts fieldNames in place for display, using the raw field
+// names so styling can't affect the order. Pinned fields come first; the rest
+// follow alphabetically, or in source order when configured and entry carries
+// it. Fields matching the same pinned wildcard are ordered the same way.
+func orderFieldNames(fieldNames []string, order *FieldOrderConfig, entry *LogEntry) {
+	var pinned []string
+	if order != nil {
+		pinned = order.Pinned
+	}
+
+	pinRank := make(map[string]int, len(fieldNames))
+	for _, fieldName := range fieldNames {
+		pinRank[fieldName] = len(pinned)
+		for i, pattern := range pinned {
+			if isFieldInSlice([]string{pattern}, fieldName) {
+				pinRank[fieldName] = i
+				break
+			}
+		}
+	}
+
+	var sourceIndex map[string]int
+	if order.sortsBySource() && entry != nil && len(entry.SourceOrder) > 0 {
+		sourceIndex = make(map[string]int, len(entry.SourceOrder))
+		for i, fieldName := range entry.SourceOrder {
+			sourceIndex[fieldName] = i
+		}
+	}
+
+	sort.SliceStable(fieldNames, func(i, j int) bool {
+		a, b := fieldNames[i], fieldNames[j]
+
+		if pinRank[a] != pinRank[b] {
+			return pinRank[a] < pinRank[b]
+		}
+
+		if sourceIndex != nil {
+			ia, okA := sourceIndex[a]
+			ib, okB := sourceIndex[b]
+			if okA && okB && ia != ib {
+				return ia < ib
+			}
+			if okA != okB {
+				return okA
+			}
+		}
+
+		return a < b
+	})
+}
+
+// jsonKeyOrder returns the flattened names of the values in a JSON object in
+// the order they appear in the document, using the same dotted naming as
+// flattenJSON. It returns nil if data isn't a JSON object.
+func jsonKeyOrder(data []byte) []string {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+
+	var order []string
+	var walkObject func(prefix string) bool
+	walkObject = func(prefix string) bool {
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return false
+			}
+			key, _ := token.(string)
+
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+
+			token, err = decoder.Token()
+			if err != nil {
+				return false
+			}
+
+			switch token {
+			case json.Delim('{'):
+				if !walkObject(name) {
+					return false
+				}
+			case json.Delim('['):
+				order = append(order, name)
+				if !skipJSONArray(decoder) {
+					return false
+				}
+			default:
+				order = append(order, name)
+			}
+		}
+
+		// Consume the closing '}'.
+		_, err := decoder.Token()
+		return err == nil
+	}
+
+	if !walkObject("") {
+		return nil
+	}
+	return order
+}
+
+// skipJSONArray consumes the rest of an array whose opening '[' has been read.
+func skipJSONArray(decoder *json.Decoder) bool {
+	depth := 1
+	for depth > 0 {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		switch token {
+		case json.Delim('['), json.Delim('{'):
+			depth++
+		case json.Delim(']'), json.Delim('}'):
+			depth--
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrderFieldNames(t *testing.T) {
+	t.Run("sorts raw names alphabetically by default", func(t *testing.T) {
+		names := []string{"user", "http.status", "trace.id"}
+		orderFieldNames(names, nil, nil)
+
+		if got := strings.Join(names, ","); got != "http.status,trace.id,user" {
+			t.Errorf("orderFieldNames() = %s", got)
+		}
+	})
+
+	t.Run("puts pinned fields first in the order listed, wildcards included", func(t *testing.T) {
+		order := &FieldOrderConfig{Pinned: []string{"trace.id", "http.*"}, Sort: fieldOrderAlphabetical}
+		names := []string{"user", "http.status", "http.method", "trace.id", "app"}
+		orderFieldNames(names, order, nil)
+
+		if got := strings.Join(names, ","); got != "trace.id,http.method,http.status,app,user" {
+			t.Errorf("orderFieldNames() = %s", got)
+		}
+	})
+
+	t.Run("keeps source order for the rest when configured", func(t *testing.T) {
+		order := &FieldOrderConfig{Pinned: []string{"trace.id"}, Sort: fieldOrderSource}
+		entry := &LogEntry{SourceOrder: []string{"msg", "user", "http.status", "trace.id", "app"}}
+		names := []string{"app", "http.status", "trace.id", "user", "extra"}
+		orderFieldNames(names, order, entry)
+
+		if got := strings.Join(names, ","); got != "trace.id,user,http.status,app,extra" {
+			t.Errorf("orderFieldNames() = %s", got)
+		}
+	})
+}
+
+func TestJSONKeyOrder(t *testing.T) {
+	t.Run("returns flattened keys in document order", func(t *testing.T) {
+		got := jsonKeyOrder([]byte(`{"msg":"hi","z":1,"http":{"status":200,"request":{"method":"GET"}},"tags":["a",{"b":1}],"a":null}`))
+		want := "msg,z,http.status,http.request.method,tags,a"
+
+		if strings.Join(got, ",") != want {
+			t.Errorf("jsonKeyOrder() = %v, want %s", got, want)
+		}
+	})
+
+	t.Run("returns nil for anything but a JSON object", func(t *testing.T) {
+		if got := jsonKeyOrder([]byte(`[1,2]`)); got != nil {
+			t.Errorf("jsonKeyOrder() = %v, want nil", got)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import "strings"
+
+// fieldTreeNode is one node of the object hierarchy rebuilt from an entry's
+// flattened fields for --tree output. Leaves are the fields themselves;
+// branches are the JSON objects that contained them.
+type fieldTreeNode struct {
+	// Label is the key shown for the node: one JSON key, or several joined with
+	// dots once a single-child chain has been collapsed.
+	Label string
+	// Name is the flattened name of the field (leaves) or of the object
+	// (branches), e.g. "http.request". Styling is keyed on this name so styles
+	// and highlights match exactly as they do in the flat output.
+	Name     string
+	Children []*fieldTreeNode
+	leaf     bool
+}
+
+func (n *fieldTreeNode) isLeaf() bool {
+	return n.leaf
+}
+
+// buildFieldTree rebuilds the JSON object hierarchy for fieldNames from their
+// recorded paths, keeping fieldNames' order among siblings, and collapses
+// single-child chains into dotted labels.
+func buildFieldTree(fieldNames []string, paths map[string][]string) []*fieldTreeNode {
+	root := &fieldTreeNode{}
+
+	for _, fieldName := range fieldNames {
+		path := paths[fieldName]
+		if len(path) == 0 {
+			path = []string{fieldName}
+		}
+
+		node := root
+		for i, segment := range path {
+			isLeaf := i == len(path)-1
+			node = node.child(segment, strings.Join(path[:i+1], "."), isLeaf)
+		}
+	}
+
+	return collapseFieldTree(root.Children)
+}
+
+// child returns the child with the given label, creating it if needed. Leaves
+// and branches never share a node, so a field and an object with the same key
+// both show up.
+func (n *fieldTreeNode) child(label, name string, isLeaf bool) *fieldTreeNode {
+	if !isLeaf {
+		for _, c := range n.Children {
+			if c.Label == label && !c.isLeaf() {
+				return c
+			}
+		}
+	}
+
+	c := &fieldTreeNode{Label: label, Name: name, leaf: isLeaf}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+// collapseFieldTree merges every branch that has exactly one child into that
+// child, so {"kubernetes":{"pod":{"name":"x"}}} shows as "kubernetes.pod.name: x"
+// rather than three levels of nesting.
+func collapseFieldTree(nodes []*fieldTreeNode) []*fieldTreeNode {
+	for _, node := range nodes {
+		for !node.leaf && len(node.Children) == 1 {
+			only := node.Children[0]
+			node.Label = node.Label + "." + only.Label
+			node.Name = only.Name
+			node.Children = only.Children
+			node.leaf = only.leaf
+		}
+		node.Children = collapseFieldTree(node.Children)
+	}
+	return nodes
+}
+
+// Indentation guides drawn in front of tree nodes.
+const (
+	treeGuideBranch = "├─ "
+	treeGuideLast   = "└─ "
+	treeGuideLine   = "│  "
+	treeGuideBlank  = "   "
+)
+
+// renderFieldTree renders the tree as lines, each prefixed by indent. Top-level
+// nodes have no guide; nested nodes hang off their parent with box-drawing
+// guides. formatKey and formatValue style a node's label and a leaf's value.
+func renderFieldTree(nodes []*fieldTreeNode, indent string, formatKey func(node *fieldTreeNode) string, formatValue func(node *fieldTreeNode) string) []string {
+	var lines []string
+
+	var walk func(nodes []*fieldTreeNode, guide string, nested bool)
+	walk = func(nodes []*fieldTreeNode, guide string, nested bool) {
+		for i, node := range nodes {
+			last := i == len(nodes)-1
+
+			linePrefix, childGuide := guide, guide
+			if nested {
+				if last {
+					linePrefix += treeGuideLast
+					childGuide += treeGuideBlank
+				} else {
+					linePrefix += treeGuideBranch
+					childGuide += treeGuideLine
+				}
+			}
+
+			if node.isLeaf() {
+				lines = append(lines, indent+linePrefix+formatKey(node)+": "+formatValue(node))
+				continue
+			}
+
+			lines = append(lines, indent+linePrefix+formatKey(node)+":")
+			walk(node.Children, childGuide, true)
+		}
+	}
+	walk(nodes, "", false)
+
+	return lines
+}
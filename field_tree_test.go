@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildFieldTree(t *testing.T) {
+	plainKey := func(node *fieldTreeNode) string { return node.Label }
+	plainValue := func(node *fieldTreeNode) string { return "<" + node.Name + ">" }
+
+	t.Run("nests fields under their objects with indentation guides", func(t *testing.T) {
+		fieldNames := []string{"http.request.method", "http.request.path", "http.status", "trace.id"}
+		paths := map[string][]string{
+			"http.request.method": {"http", "request", "method"},
+			"http.request.path":   {"http", "request", "path"},
+			"http.status":         {"http", "status"},
+			"trace.id":            {"trace.id"},
+		}
+
+		got := renderFieldTree(buildFieldTree(fieldNames, paths), "  ", plainKey, plainValue)
+		want := []string{
+			"  http:",
+			"  ├─ request:",
+			"  │  ├─ method: <http.request.method>",
+			"  │  └─ path: <http.request.path>",
+			"  └─ status: <http.status>",
+			"  trace.id: <trace.id>",
+		}
+
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("tree =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	})
+
+	t.Run("collapses single-child chains into dotted labels", func(t *testing.T) {
+		fieldNames := []string{"kubernetes.pod.name", "log.origin.file.line", "log.origin.file.name"}
+		paths := map[string][]string{
+			"kubernetes.pod.name":  {"kubernetes", "pod", "name"},
+			"log.origin.file.line": {"log", "origin", "file", "line"},
+			"log.origin.file.name": {"log", "origin", "file", "name"},
+		}
+
+		got := renderFieldTree(buildFieldTree(fieldNames, paths), "", plainKey, plainValue)
+		want := []string{
+			"kubernetes.pod.name: <kubernetes.pod.name>",
+			"log.origin.file:",
+			"├─ line: <log.origin.file.line>",
+			"└─ name: <log.origin.file.name>",
+		}
+
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("tree =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	})
+
+	t.Run("keys styling on the flattened name of collapsed branches", func(t *testing.T) {
+		paths := map[string][]string{
+			"a.b.c": {"a", "b", "c"},
+			"a.b.d": {"a", "b", "d"},
+		}
+
+		tree := buildFieldTree([]string{"a.b.c", "a.b.d"}, paths)
+		if len(tree) != 1 || tree[0].Label != "a.b" || tree[0].Name != "a.b" {
+			t.Fatalf("root = %+v, want a single collapsed branch a.b", tree[0])
+		}
+	})
+
+	t.Run("treats fields without a recorded path as top-level leaves", func(t *testing.T) {
+		got := renderFieldTree(buildFieldTree([]string{"foo.bar"}, nil), "", plainKey, plainValue)
+		if len(got) != 1 || got[0] != "foo.bar: <foo.bar>" {
+			t.Errorf("tree = %q, want a single foo.bar leaf", got)
+		}
+	})
+}
@@ -8,11 +8,9 @@ go 1.19
 
 require (
 	github.com/fatih/color v1.13.0
+	github.com/mattn/go-isatty v0.0.14
 	github.com/sirupsen/logrus v1.9.0
+	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8
 )
 
-require (
-	github.com/mattn/go-colorable v0.1.9 // indirect
-	github.com/mattn/go-isatty v0.0.14 // indirect
-	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
-)
+require github.com/mattn/go-colorable v0.1.9 // indirect
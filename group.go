@@ -11,12 +11,23 @@ import (
 )
 
 // traceGroup is a set of log entries that share the same grouping value (e.g.
-// the same trace id), in display order.
+// the same trace id), in display order. With nested --group-by levels, the
+// entries are also grouped by the next level into Subgroups, with Ungrouped
+// holding those without a value for it and Hidden counting the subgroups
+// --group-limit left out.
 type traceGroup struct {
 	Key     string
 	Entries []*LogEntry
+
+	Subgroups []*traceGroup
+	Ungrouped []*LogEntry
+	Hidden    int
+	nested    bool
 }
 
+// groupIndent is how far each nested --group-by level is indented.
+const groupIndent = "  "
+
 // groupHeaderRule is the bracketing rule drawn on either side of a group header.
 const groupHeaderRule = "══"
 
@@ -132,17 +143,17 @@ func parseEntryTime(entry *LogEntry) (time.Time, bool) {
 
 // hopPath returns the distinct app names the entries pass through, in
 // first-appearance order, so a header can show how a call travelled across
-// services. Pods with no identity (unprefixed input) are skipped.
+// services. Entries without an app (unprefixed input and no AppNames field
+// rule) are skipped.
 func hopPath(entries []*LogEntry) []string {
 	var path []string
 	seen := make(map[string]struct{})
 
 	for _, entry := range entries {
-		if entry.PodID == "" {
+		app := entryApp(entry)
+		if app == "" {
 			continue
 		}
-
-		app := trimPodHash(entry.PodID)
 		if _, dup := seen[app]; dup {
 			continue
 		}
@@ -169,60 +180,134 @@ func trimPodHash(podID string) string {
 }
 
 // renderGroups prints each trace group, separated by a blank line, followed by
-// any ungrouped entries. Individual entries reuse the normal single/multi-line
-// rendering so all styling, filtering and flags keep working inside a group.
-func renderGroups(args Args, config Config, groups []*traceGroup, ungrouped []*LogEntry, colorizer *PodColorizer) {
-	label := groupLabel(args.GroupBy)
-	printedAny := false
+// a note of how many groups --group-limit left out and any ungrouped entries.
+// Individual entries reuse the normal single/multi-line rendering so all
+// styling, filtering and flags keep working inside a group. Delta and elapsed
+// timestamps restart at the top of each group.
+func renderGroups(args Args, config Config, groups []*traceGroup, ungrouped []*LogEntry, hidden int, colorizer *PodColorizer, timeFormatter *TimeFormatter) {
+	printGroupLevel(args, config, groups, ungrouped, hidden, 0, colorizer, timeFormatter)
+}
 
-	for _, group := range groups {
-		if printedAny {
+// printGroupLevel prints the groups of one --group-by level, indented by their
+// depth. Only the top level is separated by blank lines.
+func printGroupLevel(args Args, config Config, groups []*traceGroup, ungrouped []*LogEntry, hidden, depth int, colorizer *PodColorizer, timeFormatter *TimeFormatter) {
+	indent := strings.Repeat(groupIndent, depth)
+	printedAny := false
+	separate := func() {
+		if printedAny && depth == 0 {
 			fmt.Println()
 		}
 		printedAny = true
+	}
 
-		fmt.Println(formatGroupHeader(label, group.Key, group.Entries))
-		for _, entry := range group.Entries {
-			printEntry(args, config, entry, colorizer)
-		}
+	for _, group := range groups {
+		separate()
+		printGroup(args, config, group, depth, colorizer, timeFormatter)
 	}
 
-	if len(ungrouped) > 0 {
-		if printedAny {
-			fmt.Println()
-		}
+	if hidden > 0 {
+		separate()
+		fmt.Println(indent + groupHeaderStyle().Sprint(hiddenGroupsText(hidden)))
+	}
 
-		fmt.Println(formatUngroupedHeader(len(ungrouped)))
+	if len(ungrouped) > 0 {
+		separate()
+		fmt.Println(indent + formatUngroupedHeader(len(ungrouped)))
+		timeFormatter.Reset()
 		for _, entry := range ungrouped {
-			printEntry(args, config, entry, colorizer)
+			printIndentedEntry(args, config, entry, indent, colorizer, timeFormatter)
 		}
 	}
 }
 
-// formatGroupHeader builds the header line for a trace group, e.g.
-// "══ trace.id=abc123 · 5 lines · gateway → billing → auth ══". The app
-// hop-path is omitted when no entry carries a pod identity.
-func formatGroupHeader(label, key string, entries []*LogEntry) string {
-	parts := []string{
-		fmt.Sprintf("%s=%s", label, key),
-		lineCount(len(entries)),
+// printGroup prints a group's header followed by its subgroups, or by its
+// entries at the innermost --group-by level.
+func printGroup(args Args, config Config, group *traceGroup, depth int, colorizer *PodColorizer, timeFormatter *TimeFormatter) {
+	indent := strings.Repeat(groupIndent, depth)
+	fmt.Println(indent + formatGroupHeader(config, groupLabel(args.GroupBy[depth]), group))
+
+	if group.nested {
+		printGroupLevel(args, config, group.Subgroups, group.Ungrouped, group.Hidden, depth+1, colorizer, timeFormatter)
+		return
+	}
+	printGroupEntries(args, config, group, indent, colorizer, timeFormatter)
+}
+
+// printGroupEntries prints the entries under a group header: as a span
+// waterfall with --waterfall when they carry span ids, else in time order.
+func printGroupEntries(args Args, config Config, group *traceGroup, indent string, colorizer *PodColorizer, timeFormatter *TimeFormatter) {
+	timeFormatter.Reset()
+
+	if args.Waterfall && hasSpans(group.Entries, *config.Keywords) {
+		printWaterfall(args, config, group, indent, colorizer, timeFormatter)
+		return
+	}
+
+	for _, entry := range group.Entries {
+		printIndentedEntry(args, config, entry, indent, colorizer, timeFormatter)
+	}
+}
+
+// printIndentedEntry prints an entry indented under a nested group header,
+// wrapping it to the width left after the indent.
+func printIndentedEntry(args Args, config Config, entry *LogEntry, indent string, colorizer *PodColorizer, timeFormatter *TimeFormatter) {
+	if indent == "" || !entry.IsParsed {
+		printEntry(args, config, entry, colorizer, timeFormatter)
+		return
 	}
 
-	if path := hopPath(entries); len(path) > 0 {
-		parts = append(parts, strings.Join(path, " → "))
+	if args.Width > len(indent) {
+		args.Width -= len(indent)
 	}
+	fmt.Println(indentLines(formatEntry(args, config, entry, colorizer, timeFormatter), indent))
+}
+
+// formatGroupHeader builds the header line for a trace group, e.g.
+// "══ trace.id=abc123 · 5 lines · 1.2s · 1 error, 4 info · gateway → billing ══",
+// with the count of the worst level in that level's style. Which parts are
+// shown is set by GroupHeader in the config file.
+func formatGroupHeader(config Config, label string, group *traceGroup) string {
+	return renderGroupHeader(config, label, group, func(level, text string) string {
+		if level == "" {
+			return groupHeaderStyle().Sprint(text)
+		}
+		return styleString(resolveLevelStyle(level, config.LevelStyles), text)
+	})
+}
 
-	return groupHeaderStyle().Sprintf("%s %s %s", groupHeaderRule, strings.Join(parts, " · "), groupHeaderRule)
+// groupHeaderText is the unstyled text of a group header, shared by the
+// terminal and HTML renderers.
+func groupHeaderText(config Config, label string, group *traceGroup) string {
+	return renderGroupHeader(config, label, group, func(_, text string) string {
+		return text
+	})
 }
 
 // formatUngroupedHeader builds the header for the trailing section of entries
 // that carried none of the configured grouping fields.
 func formatUngroupedHeader(count int) string {
-	return groupHeaderStyle().Sprintf("%s ungrouped · %s %s", groupHeaderRule, lineCount(count), groupHeaderRule)
+	return groupHeaderStyle().Sprint(ungroupedHeaderText(count))
+}
+
+func ungroupedHeaderText(count int) string {
+	return fmt.Sprintf("%s ungrouped · %s %s", groupHeaderRule, lineCount(count), groupHeaderRule)
 }
 
 func groupHeaderStyle() *color.Color {
-	return color.New(color.FgHiWhite, color.Bold)
+	return newColor(color.FgHiWhite, color.Bold)
+}
+
+// flattenGroups appends the entries of the groups, in the order they're shown,
+// followed by the ungrouped entries.
+func flattenGroups(groups []*traceGroup, ungrouped []*LogEntry, entries []*LogEntry) []*LogEntry {
+	for _, group := range groups {
+		if group.nested {
+			entries = flattenGroups(group.Subgroups, group.Ungrouped, entries)
+		} else {
+			entries = append(entries, group.Entries...)
+		}
+	}
+	return append(entries, ungrouped...)
 }
 
 // groupLabel is the field name shown in group headers. The first configured
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Fields a --group-where clause can compare that describe the whole group
+// rather than a line in it.
+const (
+	groupDurationField = "group.duration"
+	groupLinesField    = "group.lines"
+)
+
+// parseGroupCondition parses a --group-where condition. It's written like any
+// other condition, and group.duration clauses must compare against a duration
+// such as 2s.
+func parseGroupCondition(source string) (*Condition, error) {
+	condition, err := parseCondition(source)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, clause := range condition.clauses {
+		if clause.Field != groupDurationField {
+			continue
+		}
+		if clause.pattern != nil {
+			return nil, fmt.Errorf("invalid condition %q: %s can't be matched with ~", source, groupDurationField)
+		}
+		if _, err := time.ParseDuration(clause.Value); err != nil {
+			return nil, fmt.Errorf("invalid condition %q: %s must be compared with a duration such as 2s", source, groupDurationField)
+		}
+	}
+
+	return condition, nil
+}
+
+// MatchesGroup reports whether a group satisfies every clause. group.duration
+// and group.lines compare the time from the group's first to its last line and
+// its number of lines. Any other clause is satisfied when a line in the group
+// matches it, so "level>=error && app=booking-api" matches a trace with an
+// error that passed through booking-api, even when they're different lines.
+func (c *Condition) MatchesGroup(entries []*LogEntry, logLevelToSeverity map[string]int) bool {
+	if c == nil {
+		return false
+	}
+	for _, clause := range c.clauses {
+		if !clause.matchesGroup(entries, logLevelToSeverity) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c conditionClause) matchesGroup(entries []*LogEntry, logLevelToSeverity map[string]int) bool {
+	switch c.Field {
+	case groupDurationField:
+		first, last, ok := timeRange(entries)
+		want, err := time.ParseDuration(c.Value)
+		if !ok || err != nil {
+			return false
+		}
+		duration := last.Sub(first)
+		switch {
+		case duration < want:
+			return compareOrdered(-1, c.Operator)
+		case duration > want:
+			return compareOrdered(1, c.Operator)
+		}
+		return compareOrdered(0, c.Operator)
+	case groupLinesField:
+		lines := &LogEntry{Fields: map[string]string{groupLinesField: strconv.Itoa(len(entries))}}
+		return c.matches(lines, logLevelToSeverity)
+	}
+
+	for _, entry := range entries {
+		if c.matches(entry, logLevelToSeverity) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGroupFiltered reports whether --group-where or --group-min-level is given.
+func isGroupFiltered(args Args) bool {
+	return args.GroupWhere != nil || args.GroupMinLevel != ""
+}
+
+// shouldShowGroup applies --group-where and --group-min-level to a complete
+// group.
+func shouldShowGroup(args Args, config Config, entries []*LogEntry) bool {
+	if args.GroupWhere != nil && !args.GroupWhere.MatchesGroup(entries, config.LogLevelToSeverity) {
+		return false
+	}
+
+	if args.GroupMinLevel != "" {
+		minSeverity := config.LogLevelToSeverity[args.GroupMinLevel]
+		for _, entry := range entries {
+			if config.LogLevelToSeverity[entry.Level] >= minSeverity {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// groupAndFilterEntries groups the entries by every --group-by level, leaves
+// out the groups that --group-where and --group-min-level don't match, and
+// orders and limits the rest with --group-sort and --group-limit, returning how
+// many groups the limit left out. Lines without a grouping value belong to no
+// group that could match, so they're left out too when groups are filtered.
+func groupAndFilterEntries(args Args, config Config, entries []*LogEntry) (groups []*traceGroup, ungrouped []*LogEntry, hidden int) {
+	return groupAndFilterLevel(args, config, entries, 0)
+}
+
+// groupAndFilterLevel groups entries by the --group-by level at depth, and
+// each group by the levels below it. Sorting and limiting apply at every level.
+func groupAndFilterLevel(args Args, config Config, entries []*LogEntry, depth int) (groups []*traceGroup, ungrouped []*LogEntry, hidden int) {
+	groups, ungrouped = groupEntries(entries, args.GroupBy[depth])
+
+	shown := groups[:0]
+	for _, group := range groups {
+		if nestGroup(args, config, group, depth) {
+			shown = append(shown, group)
+		} else {
+			logDebug("Not showing group %s\n", group.Key)
+		}
+	}
+	groups = shown
+	if isGroupFiltered(args) {
+		ungrouped = nil
+	}
+
+	sortGroups(groups, args.GroupSort, config.LogLevelToSeverity)
+	groups, hidden = limitGroups(groups, args.GroupLimit)
+	return groups, ungrouped, hidden
+}
+
+// nestGroup groups a group's entries by the --group-by levels below depth, and
+// reports whether it should be shown. Group filters pick the groups of the
+// innermost level, such as the traces with an error, and the groups above them
+// are shown when any of theirs is.
+func nestGroup(args Args, config Config, group *traceGroup, depth int) bool {
+	if depth+1 >= len(args.GroupBy) {
+		return shouldShowGroup(args, config, group.Entries)
+	}
+
+	group.nested = true
+	group.Subgroups, group.Ungrouped, group.Hidden = groupAndFilterLevel(args, config, group.Entries, depth+1)
+	return !isGroupFiltered(args) || len(group.Subgroups) > 0
+}
@@ -0,0 +1,106 @@
+package main
+
+import "testing"
+
+func TestConditionMatchesGroup(t *testing.T) {
+	severity := newDefaultConfig().LogLevelToSeverity
+	entries := []*LogEntry{
+		{PodID: "api-gateway-7d8f9b6c5-x2k4p", Time: "2026-06-25T12:00:00Z", Level: "info", Fields: map[string]string{"http.status": "200"}},
+		{PodID: "booking-api-5c4b3a2d1-qq9zz", Time: "2026-06-25T12:00:02.500Z", Level: "error"},
+	}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"level>=error", true},
+		{"level>=fatal", false},
+		{"app=booking-api", true},
+		{"app=payment-api", false},
+		{"level>=error && http.status=200", true},
+		{"group.duration>2s", true},
+		{"group.duration>=2.5s", true},
+		{"group.duration<1s", false},
+		{"group.lines=2", true},
+		{"group.lines>2", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			condition, err := parseGroupCondition(tt.condition)
+			if err != nil {
+				t.Fatalf("parseGroupCondition(%q) error = %v", tt.condition, err)
+			}
+			if got := condition.MatchesGroup(entries, severity); got != tt.want {
+				t.Errorf("MatchesGroup() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("group.duration doesn't match groups without timestamps", func(t *testing.T) {
+		condition, _ := parseGroupCondition("group.duration>=0s")
+		if condition.MatchesGroup([]*LogEntry{{Level: "info"}}, severity) {
+			t.Errorf("MatchesGroup() = true, want false")
+		}
+	})
+}
+
+func TestParseGroupConditionErrors(t *testing.T) {
+	for _, condition := range []string{"group.duration>2", "group.duration~2s", "level"} {
+		if _, err := parseGroupCondition(condition); err == nil {
+			t.Errorf("parseGroupCondition(%q) should fail", condition)
+		}
+	}
+}
+
+func TestGroupAndFilterEntries(t *testing.T) {
+	config := *newDefaultConfig()
+	entries := []*LogEntry{
+		groupTestEntry(1, "", "2026-06-25T12:00:00Z", map[string]string{"trace.id": "ok"}),
+		groupTestEntry(2, "", "2026-06-25T12:00:01Z", map[string]string{"trace.id": "failed"}),
+		groupTestEntry(3, "", "2026-06-25T12:00:02Z", map[string]string{"trace.id": "failed"}),
+		groupTestEntry(4, "", "2026-06-25T12:00:03Z", map[string]string{"user": "bob"}),
+	}
+	entries[0].Level, entries[1].Level, entries[2].Level, entries[3].Level = "info", "info", "error", "error"
+
+	t.Run("keeps every line of the groups with a line at the minimum level", func(t *testing.T) {
+		args := Args{GroupBy: [][]string{{"trace.id"}}, GroupMinLevel: "error"}
+
+		groups, ungrouped, _ := groupAndFilterEntries(args, config, entries)
+
+		if len(groups) != 1 || groups[0].Key != "failed" || len(groups[0].Entries) != 2 {
+			t.Errorf("groups = %+v, want only the failed group with both lines", groups)
+		}
+		if len(ungrouped) != 0 {
+			t.Errorf("got %d ungrouped, want none while filtering groups", len(ungrouped))
+		}
+	})
+
+	t.Run("keeps every group without group filters", func(t *testing.T) {
+		groups, ungrouped, _ := groupAndFilterEntries(Args{GroupBy: [][]string{{"trace.id"}}}, config, entries)
+
+		if len(groups) != 2 || len(ungrouped) != 1 {
+			t.Errorf("got %d groups and %d ungrouped, want 2 and 1", len(groups), len(ungrouped))
+		}
+	})
+}
+
+func TestGroupAndFilterEntriesNested(t *testing.T) {
+	config := *newDefaultConfig()
+	args := Args{GroupBy: [][]string{{"service.name"}, {"trace.id"}}, GroupMinLevel: "error"}
+	entries := []*LogEntry{
+		groupTestEntry(1, "", "2026-06-25T12:00:00Z", map[string]string{"service.name": "booking", "trace.id": "ok"}),
+		groupTestEntry(2, "", "2026-06-25T12:00:01Z", map[string]string{"service.name": "booking", "trace.id": "failed"}),
+		groupTestEntry(3, "", "2026-06-25T12:00:02Z", map[string]string{"service.name": "payment", "trace.id": "ok"}),
+	}
+	entries[0].Level, entries[1].Level, entries[2].Level = "info", "error", "info"
+
+	groups, _, _ := groupAndFilterEntries(args, config, entries)
+
+	if len(groups) != 1 || groups[0].Key != "booking" {
+		t.Fatalf("got %d groups, want only booking", len(groups))
+	}
+	if subgroups := groups[0].Subgroups; len(subgroups) != 1 || subgroups[0].Key != "failed" {
+		t.Errorf("booking has %d subgroups, want only the failed trace", len(subgroups))
+	}
+}
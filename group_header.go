@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Parts a group header can show, listed in GroupHeader in the config file.
+// field:<name> summarizes the distinct values of a field, e.g.
+// field:http.route.
+const (
+	groupHeaderKey         = "key"
+	groupHeaderGroups      = "groups"
+	groupHeaderLines       = "lines"
+	groupHeaderDuration    = "duration"
+	groupHeaderLevels      = "levels"
+	groupHeaderPods        = "pods"
+	groupHeaderApps        = "apps"
+	groupHeaderFieldPrefix = "field:"
+)
+
+// groupHeaderFieldValues is how many distinct values a field summary lists
+// before counting the rest.
+const groupHeaderFieldValues = 3
+
+func defaultGroupHeader() []string {
+	return []string{groupHeaderKey, groupHeaderGroups, groupHeaderLines, groupHeaderDuration, groupHeaderLevels, groupHeaderPods, groupHeaderApps}
+}
+
+// validateGroupHeader describes the GroupHeader parts that aren't known.
+func validateGroupHeader(parts []string) []string {
+	var problems []string
+	for _, part := range parts {
+		switch part {
+		case groupHeaderKey, groupHeaderGroups, groupHeaderLines, groupHeaderDuration, groupHeaderLevels, groupHeaderPods, groupHeaderApps:
+			continue
+		}
+		if field := strings.TrimPrefix(part, groupHeaderFieldPrefix); field != part && field != "" {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("GroupHeader: unknown part %q, must be one of key|groups|lines|duration|levels|pods|apps|field:<name>", part))
+	}
+	return problems
+}
+
+// headerText is a piece of a group header. Level is set for the count of the
+// group's worst level, which is shown in that level's style.
+type headerText struct {
+	Level string
+	Text  string
+}
+
+// renderGroupHeader lays out the group header's parts between the rules, with
+// render styling each run of text that shares a level.
+func renderGroupHeader(config Config, label string, group *traceGroup, render func(level, text string) string) string {
+	texts := []headerText{{Text: groupHeaderRule + " "}}
+	for i, part := range groupHeaderParts(config, label, group) {
+		if i > 0 {
+			texts = append(texts, headerText{Text: " · "})
+		}
+		texts = append(texts, part...)
+	}
+	texts = append(texts, headerText{Text: " " + groupHeaderRule})
+
+	var b strings.Builder
+	for start := 0; start < len(texts); {
+		end := start
+		var run strings.Builder
+		for ; end < len(texts) && texts[end].Level == texts[start].Level; end++ {
+			run.WriteString(texts[end].Text)
+		}
+		b.WriteString(render(texts[start].Level, run.String()))
+		start = end
+	}
+	return b.String()
+}
+
+// groupHeaderParts builds the parts of a group header in GroupHeader order.
+// Parts with nothing to show are left out, such as the duration when no line
+// has a timestamp or the apps when no line has a pod.
+func groupHeaderParts(config Config, label string, group *traceGroup) [][]headerText {
+	entries := group.Entries
+	layout := config.GroupHeader
+	if len(layout) == 0 {
+		layout = defaultGroupHeader()
+	}
+
+	var parts [][]headerText
+	add := func(text string) {
+		parts = append(parts, []headerText{{Text: text}})
+	}
+
+	for _, part := range layout {
+		switch part {
+		case groupHeaderKey:
+			add(fmt.Sprintf("%s=%s", label, group.Key))
+		case groupHeaderGroups:
+			if group.nested {
+				add(plural(len(group.Subgroups)+group.Hidden, "group"))
+			}
+		case groupHeaderLines:
+			add(lineCount(len(entries)))
+		case groupHeaderDuration:
+			if first, last, ok := timeRange(entries); ok && len(entries) > 1 {
+				add(formatDuration(last.Sub(first)))
+			}
+		case groupHeaderLevels:
+			if counts := countLevels(entries, config.LogLevelToSeverity); len(counts) > 0 {
+				parts = append(parts, levelCountTexts(counts))
+			}
+		case groupHeaderPods:
+			if pods := countPods(entries); pods > 0 {
+				add(plural(pods, "pod"))
+			}
+		case groupHeaderApps:
+			if path := hopPath(entries); len(path) > 0 {
+				add(strings.Join(path, " → "))
+			}
+		default:
+			if field := strings.TrimPrefix(part, groupHeaderFieldPrefix); field != part && field != "" {
+				if summary, ok := fieldSummary(entries, field); ok {
+					add(summary)
+				}
+			}
+		}
+	}
+	return parts
+}
+
+// levelCount is how many lines of a group have a level.
+type levelCount struct {
+	Level string
+	Count int
+}
+
+// countLevels counts the group's lines per level, worst level first. Levels
+// without a severity come last.
+func countLevels(entries []*LogEntry, severity map[string]int) []levelCount {
+	index := make(map[string]int)
+	var counts []levelCount
+	for _, entry := range entries {
+		if entry.Level == "" {
+			continue
+		}
+		if i, ok := index[entry.Level]; ok {
+			counts[i].Count++
+			continue
+		}
+		index[entry.Level] = len(counts)
+		counts = append(counts, levelCount{Level: entry.Level, Count: 1})
+	}
+
+	sortLevelCounts(counts, severity)
+	return counts
+}
+
+// sortLevelCounts orders level counts worst level first. Levels without a
+// severity come last, by name.
+func sortLevelCounts(counts []levelCount, severity map[string]int) {
+	rank := func(level string) int {
+		if s, ok := severity[level]; ok {
+			return s
+		}
+		return -1
+	}
+	sort.SliceStable(counts, func(i, j int) bool {
+		if ri, rj := rank(counts[i].Level), rank(counts[j].Level); ri != rj {
+			return ri > rj
+		}
+		return counts[i].Level < counts[j].Level
+	})
+}
+
+// levelCountTexts lays out level counts, e.g. "1 error, 4 info", marking the
+// worst level's count to be shown in that level's style.
+func levelCountTexts(counts []levelCount) []headerText {
+	texts := []headerText{{Level: counts[0].Level, Text: fmt.Sprintf("%d %s", counts[0].Count, counts[0].Level)}}
+	for _, count := range counts[1:] {
+		texts = append(texts, headerText{Text: fmt.Sprintf(", %d %s", count.Count, count.Level)})
+	}
+	return texts
+}
+
+// countPods counts the distinct pods that logged in the group.
+func countPods(entries []*LogEntry) int {
+	pods := make(map[string]struct{})
+	for _, entry := range entries {
+		if entry.PodID != "" {
+			pods[entry.PodID] = struct{}{}
+		}
+	}
+	return len(pods)
+}
+
+// fieldSummary lists the distinct values of a field in the order they were
+// logged, e.g. "http.route=/bookings, /payments +2 more".
+func fieldSummary(entries []*LogEntry, field string) (string, bool) {
+	var values []string
+	seen := make(map[string]struct{})
+	for _, entry := range entries {
+		value, ok := entry.Fields[field]
+		if !ok || value == "" {
+			continue
+		}
+		if _, dup := seen[value]; dup {
+			continue
+		}
+		seen[value] = struct{}{}
+		values = append(values, value)
+	}
+
+	if len(values) == 0 {
+		return "", false
+	}
+
+	if len(values) > groupHeaderFieldValues {
+		return fmt.Sprintf("%s=%s +%d more", field, strings.Join(values[:groupHeaderFieldValues], ", "), len(values)-groupHeaderFieldValues), true
+	}
+	return field + "=" + strings.Join(values, ", "), true
+}
+
+func plural(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestGroupHeaderText(t *testing.T) {
+	entries := []*LogEntry{
+		{PodID: "api-gateway-7d8f9b6c5-x2k4p", Time: "2026-06-25T12:00:00Z", Level: "info", Fields: map[string]string{"http.route": "/bookings"}},
+		{PodID: "booking-api-5c4b3a2d1-qq9zz", Time: "2026-06-25T12:00:01.250Z", Level: "error", Fields: map[string]string{"http.route": "/bookings/{id}"}},
+		{PodID: "booking-api-5c4b3a2d1-ab1cd", Time: "2026-06-25T12:00:00.500Z", Level: "info", Fields: map[string]string{"http.route": "/bookings"}},
+	}
+
+	t.Run("shows the duration and levels by default", func(t *testing.T) {
+		got := groupHeaderText(*newDefaultConfig(), "trace.id", &traceGroup{Key: "abc123", Entries: entries})
+		want := "══ trace.id=abc123 · 3 lines · 1.25s · 1 error, 2 info · 3 pods · api-gateway → booking-api ══"
+
+		if got != want {
+			t.Errorf("groupHeaderText() =\n  %q\nwant\n  %q", got, want)
+		}
+	})
+
+	t.Run("lays out the parts listed in GroupHeader", func(t *testing.T) {
+		config := *newDefaultConfig()
+		config.GroupHeader = []string{"key", "pods", "field:http.route", "field:missing"}
+
+		got := groupHeaderText(config, "trace.id", &traceGroup{Key: "abc123", Entries: entries})
+		want := "══ trace.id=abc123 · 3 pods · http.route=/bookings, /bookings/{id} ══"
+
+		if got != want {
+			t.Errorf("groupHeaderText() =\n  %q\nwant\n  %q", got, want)
+		}
+	})
+
+	t.Run("styles the worst level's count in that level's style", func(t *testing.T) {
+		var levels []string
+		renderGroupHeader(*newDefaultConfig(), "trace.id", &traceGroup{Key: "abc123", Entries: entries}, func(level, text string) string {
+			if level != "" {
+				levels = append(levels, level+":"+text)
+			}
+			return text
+		})
+
+		if len(levels) != 1 || levels[0] != "error:1 error" {
+			t.Errorf("level styled text = %v, want [error:1 error]", levels)
+		}
+	})
+}
+
+func TestCountLevels(t *testing.T) {
+	severity := newDefaultConfig().LogLevelToSeverity
+	entries := []*LogEntry{{Level: "info"}, {Level: "custom"}, {Level: "warning"}, {Level: "info"}, {Level: ""}}
+
+	got := countLevels(entries, severity)
+	want := []levelCount{{"warning", 1}, {"info", 2}, {"custom", 1}}
+
+	if len(got) != len(want) {
+		t.Fatalf("countLevels() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("countLevels()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFieldSummary(t *testing.T) {
+	var entries []*LogEntry
+	for _, route := range []string{"/a", "/b", "/a", "/c", "/d", "/e"} {
+		entries = append(entries, &LogEntry{Fields: map[string]string{"http.route": route}})
+	}
+
+	got, ok := fieldSummary(entries, "http.route")
+	if want := "http.route=/a, /b, /c +2 more"; !ok || got != want {
+		t.Errorf("fieldSummary() = %q, %t, want %q", got, ok, want)
+	}
+
+	if _, ok := fieldSummary(entries, "user"); ok {
+		t.Errorf("fieldSummary() for a field no line has should report not ok")
+	}
+}
+
+func TestValidateGroupHeader(t *testing.T) {
+	problems := validateGroupHeader([]string{"key", "levels", "field:http.route", "field:", "latency"})
+	if len(problems) != 2 {
+		t.Errorf("validateGroupHeader() = %v, want problems for field: and latency", problems)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Orders for --group-sort.
+const (
+	groupSortStart    = "start"
+	groupSortDuration = "duration"
+	groupSortSize     = "size"
+	groupSortErrors   = "errors"
+	groupSortKey      = "key"
+)
+
+// groupSortDescending is the direction each order sorts in when --group-sort
+// doesn't say: the slowest, biggest and most failing groups first, and start
+// and key in their natural order.
+var groupSortDescending = map[string]bool{
+	groupSortStart:    false,
+	groupSortDuration: true,
+	groupSortSize:     true,
+	groupSortErrors:   true,
+	groupSortKey:      false,
+}
+
+// GroupSort is how --group-by groups are ordered, e.g. duration:desc.
+type GroupSort struct {
+	By         string
+	Descending bool
+}
+
+// parseGroupSort parses <order>[:asc|desc].
+func parseGroupSort(value string) (*GroupSort, error) {
+	by, direction, hasDirection := strings.Cut(strings.TrimSpace(value), ":")
+
+	descending, ok := groupSortDescending[by]
+	if !ok {
+		return nil, fmt.Errorf("invalid group sort %q, must be one of start|duration|size|errors|key, optionally followed by :asc or :desc", value)
+	}
+
+	if hasDirection {
+		switch direction {
+		case "asc":
+			descending = false
+		case "desc":
+			descending = true
+		default:
+			return nil, fmt.Errorf("invalid group sort direction %q, must be asc or desc", direction)
+		}
+	}
+
+	return &GroupSort{By: by, Descending: descending}, nil
+}
+
+// sortGroups orders groups by the GroupSort, keeping groups that compare equal
+// in the order they started.
+func sortGroups(groups []*traceGroup, groupSort *GroupSort, logLevelToSeverity map[string]int) {
+	if groupSort == nil || (groupSort.By == groupSortStart && !groupSort.Descending) {
+		return
+	}
+
+	var compare func(a, b *traceGroup) int
+	switch groupSort.By {
+	case groupSortStart:
+		// Groups are already in start order, so reversing it is all that's left.
+		for i, j := 0, len(groups)-1; i < j; i, j = i+1, j-1 {
+			groups[i], groups[j] = groups[j], groups[i]
+		}
+		return
+	case groupSortDuration:
+		compare = func(a, b *traceGroup) int { return compareInts(int64(groupDuration(a)), int64(groupDuration(b))) }
+	case groupSortSize:
+		compare = func(a, b *traceGroup) int { return compareInts(int64(len(a.Entries)), int64(len(b.Entries))) }
+	case groupSortErrors:
+		compare = func(a, b *traceGroup) int {
+			return compareInts(int64(countErrors(a.Entries, logLevelToSeverity)), int64(countErrors(b.Entries, logLevelToSeverity)))
+		}
+	case groupSortKey:
+		compare = func(a, b *traceGroup) int { return strings.Compare(a.Key, b.Key) }
+	default:
+		return
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groupSort.Descending {
+			return compare(groups[i], groups[j]) > 0
+		}
+		return compare(groups[i], groups[j]) < 0
+	})
+}
+
+// limitGroups keeps the first limit groups, returning how many were left out.
+// A limit of 0 keeps every group.
+func limitGroups(groups []*traceGroup, limit int) (shown []*traceGroup, hidden int) {
+	if limit <= 0 || len(groups) <= limit {
+		return groups, 0
+	}
+	return groups[:limit], len(groups) - limit
+}
+
+// groupDuration is the time from a group's first to its last line, 0 when its
+// lines have no timestamps.
+func groupDuration(group *traceGroup) time.Duration {
+	first, last, ok := timeRange(group.Entries)
+	if !ok {
+		return 0
+	}
+	return last.Sub(first)
+}
+
+// countErrors counts the lines at level error or worse. A LogLevelToSeverity
+// without an error level can't say which lines are errors, so the default
+// severities are used instead.
+func countErrors(entries []*LogEntry, logLevelToSeverity map[string]int) int {
+	errorSeverity, ok := logLevelToSeverity["error"]
+	if !ok {
+		logLevelToSeverity = defaultLogLevelToSeverity()
+		errorSeverity = logLevelToSeverity["error"]
+	}
+	count := 0
+	for _, entry := range entries {
+		if logLevelToSeverity[entry.Level] >= errorSeverity {
+			count++
+		}
+	}
+	return count
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// hiddenGroupsText is the footer noting the groups --group-limit left out.
+func hiddenGroupsText(hidden int) string {
+	noun := "groups"
+	if hidden == 1 {
+		noun = "group"
+	}
+	return fmt.Sprintf("%s %d more %s hidden %s", groupHeaderRule, hidden, noun, groupHeaderRule)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseGroupSort(t *testing.T) {
+	tests := []struct {
+		value string
+		want  GroupSort
+	}{
+		{"start", GroupSort{By: "start"}},
+		{"duration", GroupSort{By: "duration", Descending: true}},
+		{"errors:asc", GroupSort{By: "errors"}},
+		{"key:desc", GroupSort{By: "key", Descending: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseGroupSort(tt.value)
+			if err != nil {
+				t.Fatalf("parseGroupSort(%q) error = %v", tt.value, err)
+			}
+			if *got != tt.want {
+				t.Errorf("parseGroupSort(%q) = %+v, want %+v", tt.value, *got, tt.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"slowest", "size:up", ""} {
+		if _, err := parseGroupSort(value); err == nil {
+			t.Errorf("parseGroupSort(%q) should fail", value)
+		}
+	}
+}
+
+func TestSortGroups(t *testing.T) {
+	severity := newDefaultConfig().LogLevelToSeverity
+	newGroups := func() []*traceGroup {
+		short := &traceGroup{Key: "b", Entries: []*LogEntry{
+			{Time: "2026-06-25T12:00:00Z", Level: "error"},
+			{Time: "2026-06-25T12:00:01Z", Level: "error"},
+		}}
+		slow := &traceGroup{Key: "c", Entries: []*LogEntry{
+			{Time: "2026-06-25T12:00:02Z", Level: "info"},
+			{Time: "2026-06-25T12:00:09Z", Level: "error"},
+			{Time: "2026-06-25T12:00:09Z", Level: "info"},
+		}}
+		single := &traceGroup{Key: "a", Entries: []*LogEntry{{Time: "2026-06-25T12:00:03Z", Level: "info"}}}
+		return []*traceGroup{short, slow, single}
+	}
+
+	tests := []struct {
+		sort string
+		want string
+	}{
+		{"start", "b,c,a"},
+		{"start:desc", "a,c,b"},
+		{"duration", "c,b,a"},
+		{"duration:asc", "a,b,c"},
+		{"size", "c,b,a"},
+		{"errors", "b,c,a"},
+		{"key", "a,b,c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			groupSort, err := parseGroupSort(tt.sort)
+			if err != nil {
+				t.Fatalf("parseGroupSort(%q) error = %v", tt.sort, err)
+			}
+
+			groups := newGroups()
+			sortGroups(groups, groupSort, severity)
+
+			keys := make([]string, 0, len(groups))
+			for _, group := range groups {
+				keys = append(keys, group.Key)
+			}
+			if got := strings.Join(keys, ","); got != tt.want {
+				t.Errorf("sortGroups(%s) = %s, want %s", tt.sort, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitGroups(t *testing.T) {
+	groups := []*traceGroup{{Key: "a"}, {Key: "b"}, {Key: "c"}}
+
+	if shown, hidden := limitGroups(groups, 2); len(shown) != 2 || hidden != 1 {
+		t.Errorf("limitGroups(2) = %d shown, %d hidden, want 2 and 1", len(shown), hidden)
+	}
+	if shown, hidden := limitGroups(groups, 0); len(shown) != 3 || hidden != 0 {
+		t.Errorf("limitGroups(0) = %d shown, %d hidden, want 3 and 0", len(shown), hidden)
+	}
+
+	if got, want := hiddenGroupsText(1), "══ 1 more group hidden ══"; got != want {
+		t.Errorf("hiddenGroupsText(1) = %q, want %q", got, want)
+	}
+}
+
+func TestGroupSortArgsRejectStreaming(t *testing.T) {
+	defer func(sort string, limit int) { *groupSortFlag, *groupLimitFlag = sort, limit }(*groupSortFlag, *groupLimitFlag)
+	*groupSortFlag, *groupLimitFlag = "duration", 10
+
+	if _, err := parseGroupSortArg(false); err != nil {
+		t.Errorf("parseGroupSortArg() failed without streaming: %v", err)
+	}
+	if _, err := parseGroupSortArg(true); err == nil {
+		t.Errorf("parseGroupSortArg() should reject --group-sort when streaming")
+	}
+
+	if limit, err := parseGroupLimitArg(false); err != nil || limit != 10 {
+		t.Errorf("parseGroupLimitArg() = %d, %v without streaming, want 10", limit, err)
+	}
+	if _, err := parseGroupLimitArg(true); err == nil {
+		t.Errorf("parseGroupLimitArg() should reject --group-limit when streaming")
+	}
+
+	*groupSortFlag, *groupLimitFlag = "", 0
+	if _, err := parseGroupSortArg(true); err != nil {
+		t.Errorf("parseGroupSortArg() failed without --group-sort: %v", err)
+	}
+	if _, err := parseGroupLimitArg(true); err != nil {
+		t.Errorf("parseGroupLimitArg() failed without --group-limit: %v", err)
+	}
+}
+
+func TestCountErrors(t *testing.T) {
+	entries := []*LogEntry{{Level: "info"}, {Level: "error"}, {Level: "fatal"}, {Level: "debug"}}
+
+	if got := countErrors(entries, newDefaultConfig().LogLevelToSeverity); got != 2 {
+		t.Errorf("countErrors() = %d, want 2", got)
+	}
+
+	withoutError := map[string]int{"info": 1, "warn": 2, "crit": 3}
+	if got := countErrors(entries, withoutError); got != 2 {
+		t.Errorf("countErrors() without an error severity = %d, want the default severities' 2", got)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultGroupMaxLines caps how many entries streaming group mode holds back
+// when --group-max-lines isn't given.
+const defaultGroupMaxLines = 10000
+
+// streamGroup is a trace group that is still collecting entries.
+type streamGroup struct {
+	traceGroup
+	lastSeen time.Time
+}
+
+// groupStream groups entries as they arrive and hands each group to flush once
+// it's complete: when no entry for its key arrived within the idle timeout,
+// when an entry matches the end condition, or, to cap memory, when it's the
+// oldest group and too many entries are held back. Groups are kept in the order
+// they started.
+type groupStream struct {
+	fields   []string
+	idle     time.Duration
+	end      *Condition
+	maxLines int
+	severity map[string]int
+
+	groups   []*streamGroup
+	index    map[string]*streamGroup
+	buffered int
+
+	flush     func(group *traceGroup)
+	ungrouped func(entry *LogEntry)
+}
+
+func newGroupStream(args Args, config Config, flush func(group *traceGroup), ungrouped func(entry *LogEntry)) *groupStream {
+	maxLines := args.GroupMaxLines
+	if maxLines <= 0 {
+		maxLines = defaultGroupMaxLines
+	}
+
+	return &groupStream{
+		fields:    args.GroupBy[0],
+		idle:      args.GroupIdle,
+		end:       args.GroupEnd,
+		maxLines:  maxLines,
+		severity:  config.LogLevelToSeverity,
+		index:     make(map[string]*streamGroup),
+		flush:     flush,
+		ungrouped: ungrouped,
+	}
+}
+
+// add files the entry under its group. Entries without a grouping value can't
+// wait for anything, so they're passed on right away.
+func (s *groupStream) add(entry *LogEntry, now time.Time) {
+	key, ok := groupKeyFor(entry, s.fields)
+	if !ok {
+		s.ungrouped(entry)
+		return
+	}
+
+	group, exists := s.index[key]
+	if !exists {
+		group = &streamGroup{traceGroup: traceGroup{Key: key}}
+		s.index[key] = group
+		s.groups = append(s.groups, group)
+	}
+	group.Entries = append(group.Entries, entry)
+	group.lastSeen = now
+	s.buffered++
+
+	if s.end.Matches(entry, s.severity) {
+		s.flushGroup(group)
+	}
+
+	for s.buffered > s.maxLines && len(s.groups) > 0 {
+		logDebug("Holding back %d lines, more than %d: flushing group %s\n", s.buffered, s.maxLines, s.groups[0].Key)
+		s.flushGroup(s.groups[0])
+	}
+}
+
+// flushIdle flushes the groups that haven't had an entry within the idle
+// timeout.
+func (s *groupStream) flushIdle(now time.Time) {
+	if s.idle <= 0 {
+		return
+	}
+
+	var idle []*streamGroup
+	for _, group := range s.groups {
+		if now.Sub(group.lastSeen) >= s.idle {
+			idle = append(idle, group)
+		}
+	}
+	for _, group := range idle {
+		s.flushGroup(group)
+	}
+}
+
+// flushAll flushes every open group, e.g. at the end of the input.
+func (s *groupStream) flushAll() {
+	for len(s.groups) > 0 {
+		s.flushGroup(s.groups[0])
+	}
+}
+
+func (s *groupStream) flushGroup(group *streamGroup) {
+	for i, open := range s.groups {
+		if open == group {
+			s.groups = append(s.groups[:i], s.groups[i+1:]...)
+			break
+		}
+	}
+	delete(s.index, group.Key)
+	s.buffered -= len(group.Entries)
+
+	sortEntriesByTime(group.Entries)
+	s.flush(&group.traceGroup)
+}
+
+// groupStreamTick is how often idle groups are looked for: a fraction of the
+// idle timeout, so groups are flushed close to on time.
+func groupStreamTick(idle time.Duration) time.Duration {
+	tick := idle / 4
+	if tick < 50*time.Millisecond {
+		return 50 * time.Millisecond
+	}
+	if tick > time.Second {
+		return time.Second
+	}
+	return tick
+}
+
+// streamAndRenderGroups is the streaming counterpart of collectAndRenderGroups,
+// for following live logs: each group is printed as soon as it's complete
+// instead of at the end of the input.
+//
+// Entries without a grouping value are printed as they come, set apart from
+// the group printed before them by a blank line. Their delta and elapsed
+// timestamps follow on from each other rather than from the groups'.
+func streamAndRenderGroups(ctx context.Context, args Args, config Config, logEntries <-chan *LogEntry, colorizer *PodColorizer, timeFormatter *TimeFormatter) {
+	printedAny, printedGroupLast := false, false
+	ungroupedTimeFormatter := newTimeFormatter(args.TimeFormat)
+
+	stream := newGroupStream(args, config, func(group *traceGroup) {
+		if !nestGroup(args, config, group, 0) {
+			logDebug("Not showing group %s\n", group.Key)
+			return
+		}
+		if printedAny {
+			fmt.Println()
+		}
+		printedAny, printedGroupLast = true, true
+
+		printGroup(args, config, group, 0, colorizer, timeFormatter)
+	}, func(entry *LogEntry) {
+		if isGroupFiltered(args) {
+			return
+		}
+		if printedGroupLast {
+			fmt.Println()
+		}
+		printedAny, printedGroupLast = true, false
+
+		printEntry(args, config, entry, colorizer, ungroupedTimeFormatter)
+	})
+
+	var tick <-chan time.Time
+	if args.GroupIdle > 0 {
+		ticker := time.NewTicker(groupStreamTick(args.GroupIdle))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-tick:
+			stream.flushIdle(now)
+		case logEntry, ok := <-logEntries:
+			if !ok {
+				stream.flushAll()
+				return
+			}
+
+			if !shouldShowLogLine(args, config, logEntry) {
+				if isDebug() {
+					fmt.Printf("Not showing log entry %d\n", logEntry.LineNumber)
+				}
+				continue
+			}
+
+			stream.add(logEntry, time.Now())
+		}
+	}
+}
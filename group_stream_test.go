@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func newTestGroupStream(t *testing.T, args Args) (*groupStream, *[]string) {
+	t.Helper()
+
+	var flushed []string
+	args.GroupBy = [][]string{{"trace.id"}}
+	stream := newGroupStream(args, *newDefaultConfig(), func(group *traceGroup) {
+		flushed = append(flushed, group.Key)
+	}, func(entry *LogEntry) {
+		flushed = append(flushed, "ungrouped:"+entry.Message)
+	})
+	return stream, &flushed
+}
+
+func traceEntry(line int, traceID, message string) *LogEntry {
+	return &LogEntry{LineNumber: line, Message: message, Fields: map[string]string{"trace.id": traceID}, IsParsed: true}
+}
+
+func TestGroupStream(t *testing.T) {
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	t.Run("flushes groups that went idle", func(t *testing.T) {
+		stream, flushed := newTestGroupStream(t, Args{GroupIdle: 5 * time.Second})
+
+		stream.add(traceEntry(1, "a", "one"), start)
+		stream.add(traceEntry(2, "b", "two"), start.Add(3*time.Second))
+		stream.add(traceEntry(3, "a", "three"), start.Add(4*time.Second))
+
+		stream.flushIdle(start.Add(7 * time.Second))
+		if len(*flushed) != 0 {
+			t.Fatalf("flushed %q before any group was idle", *flushed)
+		}
+
+		stream.flushIdle(start.Add(9 * time.Second))
+		if !reflect.DeepEqual(*flushed, []string{"a", "b"}) {
+			t.Errorf("flushed = %q, want [a b]", *flushed)
+		}
+	})
+
+	t.Run("flushes a group when the end condition matches", func(t *testing.T) {
+		end, err := parseCondition("message~finished")
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream, flushed := newTestGroupStream(t, Args{GroupEnd: end})
+
+		stream.add(traceEntry(1, "a", "started"), start)
+		stream.add(traceEntry(2, "b", "started"), start)
+		stream.add(traceEntry(3, "b", "request finished"), start)
+
+		if !reflect.DeepEqual(*flushed, []string{"b"}) {
+			t.Errorf("flushed = %q, want [b]", *flushed)
+		}
+	})
+
+	t.Run("flushes the oldest groups when holding back too many lines", func(t *testing.T) {
+		stream, flushed := newTestGroupStream(t, Args{GroupIdle: time.Minute, GroupMaxLines: 3})
+
+		stream.add(traceEntry(1, "a", "1"), start)
+		stream.add(traceEntry(2, "b", "2"), start)
+		stream.add(traceEntry(3, "a", "3"), start)
+		stream.add(traceEntry(4, "c", "4"), start)
+
+		if !reflect.DeepEqual(*flushed, []string{"a"}) {
+			t.Errorf("flushed = %q, want [a]", *flushed)
+		}
+		if stream.buffered != 2 {
+			t.Errorf("buffered = %d, want 2", stream.buffered)
+		}
+	})
+
+	t.Run("passes entries without a grouping value on right away", func(t *testing.T) {
+		stream, flushed := newTestGroupStream(t, Args{GroupIdle: time.Minute})
+
+		stream.add(traceEntry(1, "a", "1"), start)
+		stream.add(&LogEntry{LineNumber: 2, Message: "plain", Fields: map[string]string{}}, start)
+		stream.flushAll()
+
+		if !reflect.DeepEqual(*flushed, []string{"ungrouped:plain", "a"}) {
+			t.Errorf("flushed = %q, want [ungrouped:plain a]", *flushed)
+		}
+	})
+
+	t.Run("a key seen again after its group was flushed starts a new group", func(t *testing.T) {
+		stream, flushed := newTestGroupStream(t, Args{GroupIdle: time.Second})
+
+		stream.add(traceEntry(1, "a", "1"), start)
+		stream.flushIdle(start.Add(2 * time.Second))
+		stream.add(traceEntry(2, "a", "2"), start.Add(3*time.Second))
+		stream.flushAll()
+
+		if !reflect.DeepEqual(*flushed, []string{"a", "a"}) {
+			t.Errorf("flushed = %q, want [a a]", *flushed)
+		}
+	})
+}
+
+func TestGroupStreamTick(t *testing.T) {
+	tests := map[time.Duration]time.Duration{
+		100 * time.Millisecond: 50 * time.Millisecond,
+		2 * time.Second:        500 * time.Millisecond,
+		time.Minute:            time.Second,
+	}
+	for idle, want := range tests {
+		if got := groupStreamTick(idle); got != want {
+			t.Errorf("groupStreamTick(%s) = %s, want %s", idle, got, want)
+		}
+	}
+}
+
+func TestStreamAndRenderGroupsSeparatesUngroupedEntries(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	end, err := parseCondition("message=b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeFormat, err := parseTimeFormat("delta", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := Args{GroupBy: [][]string{{"trace.id"}}, GroupEnd: end, TimeFormat: timeFormat}
+
+	entry := func(line int, time, traceID, message string) *LogEntry {
+		fields := map[string]string{}
+		if traceID != "" {
+			fields["trace.id"] = traceID
+		}
+		return &LogEntry{LineNumber: line, Time: time, Level: "info", Message: message, Fields: fields, IsParsed: true}
+	}
+	logEntries := make(chan *LogEntry, 4)
+	logEntries <- entry(1, "2026-10-19T12:00:00Z", "t1", "a")
+	logEntries <- entry(2, "2026-10-19T12:00:01Z", "t1", "b")
+	logEntries <- entry(3, "2026-10-19T12:00:02Z", "", "c")
+	logEntries <- entry(4, "2026-10-19T12:00:02.5Z", "", "d")
+	close(logEntries)
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	streamAndRenderGroups(context.Background(), args, *newDefaultConfig(), logEntries, nil, newTimeFormatter(args.TimeFormat))
+	os.Stdout = stdout
+	writer.Close()
+	out, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"══ trace.id=t1 · 2 lines · 1s · 2 info ══",
+		"[info] +0s - a - trace.id=[t1]",
+		"[info] +1.000s - b - trace.id=[t1]",
+		"",
+		"[info] +0s - c",
+		"[info] +500ms - d",
+	}, "\n") + "\n"
+	if string(out) != want {
+		t.Errorf("output =\n%s\nwant\n%s", out, want)
+	}
+}
@@ -171,10 +171,53 @@ func TestFormatGroupHeader(t *testing.T) {
 		{PodID: "booking-api-5c4b3a2d1-qq9zz"},
 	}
 
-	got := formatGroupHeader("trace.id", "abc123", entries)
-	want := "══ trace.id=abc123 · 2 lines · api-gateway → booking-api ══"
+	got := formatGroupHeader(*newDefaultConfig(), "trace.id", &traceGroup{Key: "abc123", Entries: entries})
+	want := "══ trace.id=abc123 · 2 lines · 2 pods · api-gateway → booking-api ══"
 
 	if got != want {
 		t.Errorf("formatGroupHeader() =\n  %q\nwant\n  %q", got, want)
 	}
 }
+
+func TestNestedGroups(t *testing.T) {
+	config := *newDefaultConfig()
+	args := Args{GroupBy: [][]string{{"service.name"}, {"trace.id", "labels.trace.id"}}}
+	entries := []*LogEntry{
+		groupTestEntry(1, "", "2026-06-25T12:00:00Z", map[string]string{"service.name": "booking", "trace.id": "t1"}),
+		groupTestEntry(2, "", "2026-06-25T12:00:01Z", map[string]string{"service.name": "payment", "trace.id": "t1"}),
+		groupTestEntry(3, "", "2026-06-25T12:00:02Z", map[string]string{"service.name": "booking", "labels.trace.id": "t2"}),
+		groupTestEntry(4, "", "2026-06-25T12:00:03Z", map[string]string{"service.name": "booking"}),
+		groupTestEntry(5, "", "2026-06-25T12:00:04Z", nil),
+	}
+
+	groups, ungrouped, _ := groupAndFilterEntries(args, config, entries)
+
+	if len(groups) != 2 || groups[0].Key != "booking" || groups[1].Key != "payment" {
+		t.Fatalf("got %d top-level groups, want booking and payment", len(groups))
+	}
+	if len(ungrouped) != 1 || ungrouped[0].LineNumber != 5 {
+		t.Errorf("top-level ungrouped = %v, want line 5", ungrouped)
+	}
+
+	booking := groups[0]
+	if len(booking.Subgroups) != 2 || booking.Subgroups[0].Key != "t1" || booking.Subgroups[1].Key != "t2" {
+		t.Errorf("booking has %d subgroups, want t1 and t2", len(booking.Subgroups))
+	}
+	if len(booking.Ungrouped) != 1 || booking.Ungrouped[0].LineNumber != 4 {
+		t.Errorf("booking ungrouped = %v, want line 4", booking.Ungrouped)
+	}
+
+	got := groupHeaderText(config, "service.name", booking)
+	want := "══ service.name=booking · 2 groups · 3 lines · 3s ══"
+	if got != want {
+		t.Errorf("groupHeaderText() =\n  %q\nwant\n  %q", got, want)
+	}
+
+	var order []int
+	for _, entry := range flattenGroups(groups, ungrouped, nil) {
+		order = append(order, entry.LineNumber)
+	}
+	if len(order) != 5 || order[0] != 1 || order[1] != 3 || order[2] != 4 || order[3] != 2 || order[4] != 5 {
+		t.Errorf("flattenGroups() order = %v, want [1 3 4 2 5]", order)
+	}
+}
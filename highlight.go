@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// repeatedFlag is a string flag that can be given several times, e.g.
+// -V abc -V def. Each occurrence is one term, commas included.
+type repeatedFlag []string
+
+func (f *repeatedFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(*f, ",")
+}
+
+func (f *repeatedFlag) Set(value string) error {
+	if value != "" {
+		*f = append(*f, value)
+	}
+	return nil
+}
+
+// Highlight is a --highlight-key, --highlight-value or --where term and the
+// style its matches are shown in. A term written as /regex/ is a regular
+// expression; otherwise it may have leading and/or trailing wildcards.
+type Highlight struct {
+	Term string
+	// Field limits the highlight to the values of one field, as for
+	// --where field=value. Empty means messages and every field.
+	Field string
+	// Substring highlights every occurrence of the term in a field value, as
+	// --where does when searching for a bare value, instead of requiring the
+	// value to match the term.
+	Substring bool
+	Style     *Style
+
+	pattern *regexp.Regexp
+}
+
+// newHighlight creates a highlight for term, compiling it if it's a /regex/.
+// A term that isn't a valid regular expression is matched literally.
+func newHighlight(term string, style *Style) Highlight {
+	highlight := Highlight{Term: term, Style: style}
+	if len(term) > 2 && strings.HasPrefix(term, "/") && strings.HasSuffix(term, "/") {
+		if pattern, err := regexp.Compile(term[1 : len(term)-1]); err == nil {
+			highlight.pattern = pattern
+		} else {
+			logDebug("Highlighting %s literally, it's not a valid regular expression: %v\n", term, err)
+		}
+	}
+	return highlight
+}
+
+// matches reports whether value as a whole matches the highlight.
+func (h Highlight) matches(value string) bool {
+	switch {
+	case h.pattern != nil:
+		return h.pattern.MatchString(value)
+	case h.Substring:
+		return h.Term != "" && strings.Contains(value, h.Term)
+	default:
+		return matchesHighlight(value, h.Term)
+	}
+}
+
+// spans returns the byte ranges of text to highlight, like grep --color: the
+// regex matches, or the literal part of the term. In messages every occurrence
+// is highlighted. In field values the term must match the value first (e.g.
+// "abc*" only highlights a value starting with abc), and then only the literal
+// part is highlighted, so "*abc*" marks every abc inside the value.
+func (h Highlight) spans(text string, inMessage bool) [][2]int {
+	if h.pattern != nil {
+		var spans [][2]int
+		for _, match := range h.pattern.FindAllStringIndex(text, -1) {
+			if match[0] < match[1] {
+				spans = append(spans, [2]int{match[0], match[1]})
+			}
+		}
+		return spans
+	}
+
+	literal := strings.Trim(h.Term, "*")
+	if literal == "" {
+		return nil
+	}
+
+	leading, trailing := strings.HasPrefix(h.Term, "*"), strings.HasSuffix(h.Term, "*")
+
+	switch {
+	case inMessage || h.Substring || (leading && trailing && h.matches(text)):
+		return literalSpans(text, literal)
+	case !h.matches(text):
+		return nil
+	case text == h.Term:
+		return [][2]int{{0, len(text)}}
+	case trailing:
+		return [][2]int{{0, len(literal)}}
+	default:
+		return [][2]int{{len(text) - len(literal), len(text)}}
+	}
+}
+
+func literalSpans(text, literal string) [][2]int {
+	var spans [][2]int
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], literal)
+		if i < 0 {
+			return spans
+		}
+		start := offset + i
+		spans = append(spans, [2]int{start, start + len(literal)})
+		offset = start + len(literal)
+	}
+}
+
+// highlightSpans returns the spans the highlights mark in a field's value, or
+// in the message when field is empty. Where highlights overlap, the one listed
+// first wins.
+func highlightSpans(highlights []Highlight, field, text string) []textSpan {
+	var spans []textSpan
+	for i := len(highlights) - 1; i >= 0; i-- {
+		highlight := highlights[i]
+		if highlight.Field != "" && highlight.Field != field {
+			continue
+		}
+		for _, span := range highlight.spans(text, field == "") {
+			spans = append(spans, textSpan{Start: span[0], End: span[1], Style: highlight.Style})
+		}
+	}
+	return spans
+}
+
+// defaultHighlightPalette is cycled through for the second and later highlight
+// terms; the first term uses the highlight style as configured.
+var defaultHighlightPalette = []string{"fgHiCyan", "fgHiYellow", "fgHiMagenta", "fgHiGreen", "fgHiBlue"}
+
+// newHighlights gives each key and value term its own style: the configured
+// highlight style, with the foreground color taken from the palette for every
+// term after the first. Keys and values share one sequence, so no two terms
+// look alike until the palette wraps.
+func newHighlights(config Config, keyTerms, valueTerms []string) (keys, values []Highlight) {
+	palette := config.HighlightPalette
+	if len(palette) == 0 {
+		palette = defaultHighlightPalette
+	}
+
+	styleFor := func(index int, selectStyle func(s KeyValueStyle) *Style) *Style {
+		styles := config.FieldStyles
+		if styles == nil {
+			styles = DefaultFieldStyles
+		}
+		style := highlightStyle(styles, selectStyle)
+		if index == 0 {
+			return style
+		}
+		return mergeStyles(style, &Style{FgColor: strPtr(palette[(index-1)%len(palette)])})
+	}
+
+	for i, term := range keyTerms {
+		keys = append(keys, newHighlight(term, styleFor(i, func(s KeyValueStyle) *Style { return s.Key })))
+	}
+	for i, term := range valueTerms {
+		values = append(values, newHighlight(term, styleFor(len(keyTerms)+i, func(s KeyValueStyle) *Style { return s.Value })))
+	}
+	return keys, values
+}
+
+// newWhereHighlights highlights what --where searched for, in the highlight
+// value style: a bare value wherever it occurs, and field=value in that field.
+func newWhereHighlights(config Config, whereFields map[string]string) []Highlight {
+	styles := config.FieldStyles
+	if styles == nil {
+		styles = DefaultFieldStyles
+	}
+	style := highlightStyle(styles, func(s KeyValueStyle) *Style { return s.Value })
+
+	var highlights []Highlight
+	for _, field := range sortedKeys(whereFields) {
+		highlight := Highlight{Term: whereFields[field], Style: style}
+		if field == AnyField {
+			highlight.Substring = true
+		} else {
+			highlight.Field = field
+		}
+		highlights = append(highlights, highlight)
+	}
+	return highlights
+}
+
+// findHighlight returns the style of the first highlight whose term matches
+// value, or nil.
+func findHighlight(highlights []Highlight, value string) *Style {
+	for _, highlight := range highlights {
+		if highlight.matches(value) {
+			return highlight.Style
+		}
+	}
+	return nil
+}
+
+// highlightLegend describes which color belongs to which term, e.g.
+// "Highlighting: key trace.id · value abc · value def". It is empty unless
+// there are several terms to tell apart.
+func highlightLegend(keys, values []Highlight) string {
+	if len(keys)+len(values) < 2 {
+		return ""
+	}
+
+	var parts []string
+	for _, highlight := range keys {
+		parts = append(parts, "key "+styleString(highlight.Style, highlight.Term))
+	}
+	for _, highlight := range values {
+		parts = append(parts, "value "+styleString(highlight.Style, highlight.Term))
+	}
+	return fmt.Sprintf("Highlighting: %s", strings.Join(parts, " · "))
+}
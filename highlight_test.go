@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestRepeatedFlag(t *testing.T) {
+	var values repeatedFlag
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Var(&values, "V", "")
+
+	if err := flags.Parse([]string{"-V", "abc", "-V", "def, ghi"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := strings.Join(values, "|"); got != "abc|def, ghi" {
+		t.Errorf("values = %s, want abc|def, ghi", got)
+	}
+}
+
+func TestRepeatedFlagKeepsRegexTermsWhole(t *testing.T) {
+	var values repeatedFlag
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Var(&values, "V", "")
+
+	if err := flags.Parse([]string{"-V", "/s[0-9]{1,2}/"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(values) != 1 || values[0] != "/s[0-9]{1,2}/" {
+		t.Fatalf("values = %q, want the regex as one term", []string(values))
+	}
+
+	highlight := newHighlight(values[0], &Style{Bold: boolPtr(true)})
+	if highlight.pattern == nil {
+		t.Fatalf("newHighlight(%s) didn't compile the regex", values[0])
+	}
+	var marked []string
+	for _, span := range highlightSpans([]Highlight{highlight}, "", "s1 s22 s333") {
+		marked = append(marked, "s1 s22 s333"[span.Start:span.End])
+	}
+	if got := strings.Join(marked, "|"); got != "s1|s22|s33" {
+		t.Errorf("highlightSpans() marked %q, want s1|s22|s33", got)
+	}
+}
+
+func TestNewHighlights(t *testing.T) {
+	config := *newDefaultConfig()
+	config.HighlightPalette = []string{"fgHiCyan", "fgHiYellow"}
+
+	keys, values := newHighlights(config, []string{"trace.id"}, []string{"abc", "def", "ghi"})
+
+	t.Run("the first term keeps the configured highlight style", func(t *testing.T) {
+		want := config.FieldStyles[HighlightStylesKey].Key
+		if *keys[0].Style.FgColor != *want.FgColor || !*keys[0].Style.Bold {
+			t.Errorf("first style = %+v, want the highlight key style", keys[0].Style)
+		}
+	})
+
+	t.Run("later terms take the next palette color, wrapping around", func(t *testing.T) {
+		var got []string
+		for _, highlight := range values {
+			got = append(got, *highlight.Style.FgColor)
+		}
+		if strings.Join(got, ",") != "fgHiCyan,fgHiYellow,fgHiCyan" {
+			t.Errorf("value colors = %v", got)
+		}
+		if !*values[0].Style.Underline {
+			t.Errorf("palette styles should keep the highlight style's attributes")
+		}
+	})
+
+	t.Run("values match with wildcards, first term wins", func(t *testing.T) {
+		if style := findHighlight(values, "def"); style != values[1].Style {
+			t.Errorf("findHighlight(def) = %+v, want the second value's style", style)
+		}
+		if style := findHighlight(values, "xyz"); style != nil {
+			t.Errorf("findHighlight(xyz) = %+v, want nil", style)
+		}
+	})
+}
+
+func TestHighlightLegend(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+	keys, values := newHighlights(*newDefaultConfig(), []string{"user"}, []string{"abc"})
+
+	if got := highlightLegend(keys, values); got != "Highlighting: key user · value abc" {
+		t.Errorf("highlightLegend() = %q", got)
+	}
+	if got := highlightLegend(nil, values); got != "" {
+		t.Errorf("highlightLegend() with one term = %q, want no legend", got)
+	}
+}
+
+func TestHighlightSpans(t *testing.T) {
+	style := &Style{Bold: boolPtr(true)}
+	marked := func(highlights []Highlight, field, text string) string {
+		var parts []string
+		for _, span := range highlightSpans(highlights, field, text) {
+			parts = append(parts, text[span.Start:span.End])
+		}
+		return strings.Join(parts, "|")
+	}
+
+	tests := []struct {
+		name      string
+		highlight Highlight
+		field     string
+		text      string
+		want      string
+	}{
+		{"exact value", newHighlight("abc", style), "id", "abc", "abc"},
+		{"exact term doesn't mark part of a value", newHighlight("abc", style), "id", "abcdef", ""},
+		{"trailing wildcard marks the prefix", newHighlight("abc*", style), "id", "abcdef", "abc"},
+		{"leading wildcard marks the suffix", newHighlight("*def", style), "id", "abcdef", "def"},
+		{"wildcards on both sides mark every occurrence", newHighlight("*b*", style), "id", "abcb", "b|b"},
+		{"messages mark every occurrence", newHighlight("abc", style), "", "abc and abc", "abc|abc"},
+		{"regex", newHighlight(`/\d+ms/`, style), "", "took 12ms, then 7ms", "12ms|7ms"},
+		{"invalid regex is literal", newHighlight("/(/", style), "", "a /(/ b", "/(/"},
+		{"bare --where value marks substrings", Highlight{Term: "b", Substring: true, Style: style}, "id", "abcb", "b|b"},
+		{"--where field=value only marks that field", Highlight{Term: "abc", Field: "trace.id", Style: style}, "id", "abc", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := marked([]Highlight{tt.highlight}, tt.field, tt.text); got != tt.want {
+				t.Errorf("highlightSpans() marked %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFieldValueHighlightsOnlyTheMatch(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	config := *newDefaultConfig()
+	args := Args{HighlightValues: []Highlight{newHighlight("*abc*", &Style{FgColor: strPtr("fgRed")})}}
+
+	got := formatFieldValue(args, config, "trace.id", "x-abc")
+	want := "\x1b[32mx-\x1b[0m\x1b[31mabc\x1b[0m"
+	if got != want {
+		t.Errorf("formatFieldValue() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// htmlColors maps the basic ANSI foreground colors to the CSS colors used in
+// HTML output. Background colors are looked up via their foreground twin.
+var htmlColors = map[color.Attribute]string{
+	color.FgBlack:     "#000000",
+	color.FgRed:       "#cd3131",
+	color.FgGreen:     "#0dbc79",
+	color.FgYellow:    "#e5e510",
+	color.FgBlue:      "#2472c8",
+	color.FgMagenta:   "#bc3fbc",
+	color.FgCyan:      "#11a8cd",
+	color.FgWhite:     "#e5e5e5",
+	color.FgHiBlack:   "#666666",
+	color.FgHiRed:     "#f14c4c",
+	color.FgHiGreen:   "#23d18b",
+	color.FgHiYellow:  "#f5f543",
+	color.FgHiBlue:    "#3b8eea",
+	color.FgHiMagenta: "#d670d6",
+	color.FgHiCyan:    "#29b8db",
+	color.FgHiWhite:   "#ffffff",
+}
+
+// cssColor returns the CSS color for a Style color such as "fgRed",
+// "bgHiBlue", "#ff8800" or "208".
+func cssColor(name string) (string, bool) {
+	parsed, err := parseStyleColor(name)
+	if err != nil {
+		return "", false
+	}
+
+	if rgb, ok := parsed.rgbValue(); ok {
+		return fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2]), true
+	}
+
+	attr := parsed.named
+	if (attr >= color.BgBlack && attr <= color.BgWhite) || (attr >= color.BgHiBlack && attr <= color.BgHiWhite) {
+		attr -= 10
+	}
+
+	c, ok := htmlColors[attr]
+	return c, ok
+}
+
+// styleToCSS converts a Style into CSS declarations, e.g.
+// "color:#cd3131;font-weight:bold".
+func styleToCSS(style *Style) string {
+	if style == nil {
+		return ""
+	}
+
+	var declarations []string
+	if style.FgColor != nil {
+		if c, ok := cssColor(*style.FgColor); ok {
+			declarations = append(declarations, "color:"+c)
+		}
+	}
+	if style.BgColor != nil {
+		if c, ok := cssColor(*style.BgColor); ok {
+			declarations = append(declarations, "background-color:"+c)
+		}
+	}
+	if style.Bold != nil && *style.Bold {
+		declarations = append(declarations, "font-weight:bold")
+	}
+	if style.Italic != nil && *style.Italic {
+		declarations = append(declarations, "font-style:italic")
+	}
+	if style.Underline != nil && *style.Underline {
+		declarations = append(declarations, "text-decoration:underline")
+	}
+
+	return strings.Join(declarations, ";")
+}
+
+// htmlStyleSheet hands out one CSS class per distinct style so that entries
+// sharing a style also share a class, keeping the page small.
+type htmlStyleSheet struct {
+	classes map[string]string
+	rules   []string
+}
+
+func newHTMLStyleSheet() *htmlStyleSheet {
+	return &htmlStyleSheet{classes: make(map[string]string)}
+}
+
+// classFor returns the class for style, registering it on first use. Styles
+// without any CSS equivalent get no class.
+func (s *htmlStyleSheet) classFor(style *Style) string {
+	css := styleToCSS(style)
+	if css == "" {
+		return ""
+	}
+
+	if class, ok := s.classes[css]; ok {
+		return class
+	}
+
+	class := fmt.Sprintf("s%d", len(s.classes)+1)
+	s.classes[css] = class
+	s.rules = append(s.rules, fmt.Sprintf(".%s{%s}", class, css))
+	return class
+}
+
+func (s *htmlStyleSheet) css() string {
+	return strings.Join(s.rules, "\n")
+}
+
+// htmlRenderer renders log entries as HTML using the same styles, pod colors
+// and group headers as the terminal output.
+type htmlRenderer struct {
+	args          Args
+	config        Config
+	colorizer     *PodColorizer
+	timeFormatter *TimeFormatter
+	sheet         *htmlStyleSheet
+	body          strings.Builder
+}
+
+func newHTMLRenderer(args Args, config Config, colorizer *PodColorizer, timeFormatter *TimeFormatter) *htmlRenderer {
+	return &htmlRenderer{
+		args:          args,
+		config:        config,
+		colorizer:     colorizer,
+		timeFormatter: timeFormatter,
+		sheet:         newHTMLStyleSheet(),
+	}
+}
+
+// renderHTML buffers every entry that passes the active filters and writes a
+// self-contained HTML page to stdout once the stream closes. The page is built
+// at the end because the stylesheet in <head> depends on the styles used.
+func renderHTML(ctx context.Context, args Args, config Config, logEntries <-chan *LogEntry, colorizer *PodColorizer, timeFormatter *TimeFormatter) {
+	var buffer []*LogEntry
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case logEntry, ok := <-logEntries:
+			if !ok {
+				if err := writeHTMLDocument(os.Stdout, args, config, buffer, colorizer, timeFormatter); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing HTML output: %v\n", err)
+				}
+				return
+			}
+
+			if !shouldShowLogLine(args, config, logEntry) {
+				if isDebug() {
+					fmt.Printf("Not showing log entry %d\n", logEntry.LineNumber)
+				}
+				continue
+			}
+
+			buffer = append(buffer, logEntry)
+		}
+	}
+}
+
+// writeHTMLDocument renders entries as a complete HTML page. With --group-by the
+// entries are grouped exactly like the terminal output, each group rendered as
+// a collapsible section.
+func writeHTMLDocument(w io.Writer, args Args, config Config, entries []*LogEntry, colorizer *PodColorizer, timeFormatter *TimeFormatter) error {
+	r := newHTMLRenderer(args, config, colorizer, timeFormatter)
+
+	if len(args.GroupBy) > 0 {
+		groups, ungrouped, hidden := groupAndFilterEntries(args, config, entries)
+		r.writeGroupLevel(groups, ungrouped, hidden, 0)
+	} else {
+		for _, entry := range entries {
+			r.writeEntry(entry)
+		}
+	}
+
+	_, err := fmt.Fprintf(w, htmlDocumentTemplate, htmlPageCSS, r.sheet.css(), r.body.String(), htmlFilterScript)
+	return err
+}
+
+// writeGroupLevel writes the groups of one --group-by level, with the levels
+// below nested inside them.
+func (r *htmlRenderer) writeGroupLevel(groups []*traceGroup, ungrouped []*LogEntry, hidden, depth int) {
+	label := groupLabel(r.args.GroupBy[depth])
+
+	for _, group := range groups {
+		r.openGroup(groupHeaderText(r.config, label, group))
+		if group.nested {
+			r.writeGroupLevel(group.Subgroups, group.Ungrouped, group.Hidden, depth+1)
+		} else {
+			r.writeGroupEntries(group.Entries)
+		}
+		r.body.WriteString("</details>\n")
+	}
+
+	if hidden > 0 {
+		fmt.Fprintf(&r.body, `<div class="group %s">%s</div>`+"\n", r.groupHeaderClass(), html.EscapeString(hiddenGroupsText(hidden)))
+	}
+
+	if len(ungrouped) > 0 {
+		r.openGroup(ungroupedHeaderText(len(ungrouped)))
+		r.writeGroupEntries(ungrouped)
+		r.body.WriteString("</details>\n")
+	}
+}
+
+func (r *htmlRenderer) openGroup(header string) {
+	r.body.WriteString(`<details class="group" open>`)
+	fmt.Fprintf(&r.body, `<summary class="group-header %s">%s</summary>`, r.groupHeaderClass(), html.EscapeString(header))
+}
+
+func (r *htmlRenderer) writeGroupEntries(entries []*LogEntry) {
+	r.timeFormatter.Reset()
+	for _, entry := range entries {
+		r.writeEntry(entry)
+	}
+}
+
+func (r *htmlRenderer) groupHeaderClass() string {
+	return r.sheet.classFor(&Style{FgColor: getColorCode(color.FgHiWhite), Bold: boolPtr(true)})
+}
+
+// span wraps text in a span carrying the structural class and the class for
+// style, escaping the text.
+func (r *htmlRenderer) span(class string, style *Style, text string) string {
+	if styleClass := r.sheet.classFor(style); styleClass != "" {
+		class += " " + styleClass
+	}
+	return fmt.Sprintf(`<span class="%s">%s</span>`, class, html.EscapeString(text))
+}
+
+// spannedSpan renders text like span does, with the styled spans inside it
+// (e.g. MessageRules matches and highlights) wrapped in spans of their own.
+func (r *htmlRenderer) spannedSpan(class, text string, style *Style, spans []textSpan) string {
+	if len(spans) == 0 {
+		return r.span(class, style, text)
+	}
+
+	parts := renderSpans(text, nil, spans, func(partStyle *Style, text string) string {
+		if class := r.sheet.classFor(partStyle); class != "" {
+			return fmt.Sprintf(`<span class="%s">%s</span>`, class, html.EscapeString(text))
+		}
+		return html.EscapeString(text)
+	})
+
+	if styleClass := r.sheet.classFor(style); styleClass != "" {
+		class += " " + styleClass
+	}
+	return fmt.Sprintf(`<span class="%s">%s</span>`, class, parts)
+}
+
+func (r *htmlRenderer) podPrefix(label string) string {
+	if r.colorizer == nil || label == "" {
+		return ""
+	}
+
+	return r.span("pod", &Style{FgColor: strPtr(r.colorizer.styleColorFor(label))}, "["+label+"]") + " "
+}
+
+func (r *htmlRenderer) writeEntry(logEntry *LogEntry) {
+	if !logEntry.IsParsed {
+		fmt.Fprintf(&r.body, `<div class="entry raw">%s%s</div>`+"\n", r.podPrefix(podLabel(r.config, logEntry)), html.EscapeString(strings.TrimRight(string(logEntry.OriginalLogLine), "\r\n")))
+		return
+	}
+
+	args, config := r.args, r.config
+	isMultiLine := isMultiLine()
+
+	fieldNames, hasExcludedFields := selectFields(args, config, logEntry)
+	orderFieldNames(fieldNames, config.FieldOrder, logEntry)
+	fieldNames, showError, showStack := takeErrorFields(fieldNames, logEntry)
+
+	var fields []string
+	for _, fieldName := range fieldNames {
+		value := fmtValue(args.Truncate, config.fieldFormats, fieldName, logEntry.Fields[fieldName])
+		key := r.span("key", resolveFieldNameStyle(fieldName, config.FieldStyles, args.HighlightKeys), fieldName)
+		val := r.spannedSpan("value", value, resolveFieldValueStyle(fieldName, value, config.FieldStyles), valueHighlightSpans(args, fieldName, value))
+		if url, ok := fieldLinkURL(config, logEntry, fieldName); ok {
+			val = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), val)
+		}
+
+		if isMultiLine {
+			fields = append(fields, fmt.Sprintf(`<div class="field">  %s: %s</div>`, key, val))
+		} else {
+			fields = append(fields, fmt.Sprintf("%s=[%s]", key, val))
+		}
+	}
+
+	if hasExcludedFields && len(fields) > 0 {
+		warning := r.span("excluded", resolveExcludedFieldsWarningTextStyle(config.ExcludedFieldsWarningText, config.ExcludedFieldsWarningTextStyles), config.ExcludedFieldsWarningText)
+		if isMultiLine {
+			warning = `<div class="field">  ` + warning + "</div>"
+		}
+		fields = append([]string{warning}, fields...)
+	}
+
+	lineStyle, lineMessageStyle := matchLineStyles(config, logEntry)
+	message := fmtMessage(args.Truncate, logEntry.Message)
+
+	timestamp, highlightTimestamp := r.timeFormatter.Format(logEntry)
+	timestampStyle := resolveTimestampStyle(timestamp, config.TimestampStyles)
+	if highlightTimestamp {
+		timestampStyle = resolveTimestampHighlightStyle(config.TimestampStyles)
+	}
+
+	var b strings.Builder
+	entryClass := "entry"
+	if lineClass := r.sheet.classFor(lineStyle); lineClass != "" {
+		entryClass += " " + lineClass
+	}
+	fmt.Fprintf(&b, `<div class="%s" data-level="%s">`, entryClass, html.EscapeString(logEntry.Level))
+	b.WriteString(r.podPrefix(podLabel(r.config, logEntry)))
+	messageStyle := mergeStyles(resolveMessageStyle(message, config.MessageStyles), lineMessageStyle)
+	var messageLines []string
+	for _, line := range splitMessage(message, args.MessageLayout) {
+		messageLines = append(messageLines, r.spannedSpan("message", line, messageStyle, messageSpans(args, config, line)))
+	}
+
+	// Entries keep their whitespace, so block lines are indented as in the
+	// terminal.
+	fmt.Fprintf(&b, "[%s] %s - %s",
+		r.span("level", resolveLevelStyle(logEntry.Level, config.LevelStyles), logEntry.Level),
+		r.span("time", timestampStyle, timestamp),
+		strings.Join(messageLines, "\n"+messageBlockIndent))
+
+	// After a message spanning several lines, the error and fields start on
+	// their own line, as layoutMessage does in the terminal.
+	sep := " - "
+	if len(messageLines) > 1 {
+		sep = "\n" + messageBlockIndent
+	}
+
+	errorText, stack := errorParts(args, logEntry, showError, showStack)
+	if errorText != "" {
+		if isMultiLine {
+			fmt.Fprintf(&b, `<div class="field">  %s</div>`, r.errorSpan(logEntry, errorText))
+		} else {
+			b.WriteString(sep + r.errorSpan(logEntry, errorText))
+			sep = " - "
+		}
+	}
+
+	if isMultiLine {
+		b.WriteString(r.stackBlock(stack))
+	}
+
+	if len(fields) > 0 {
+		if isMultiLine {
+			b.WriteString(strings.Join(fields, ""))
+		} else {
+			b.WriteString(sep + strings.Join(fields, " "))
+		}
+	}
+
+	if !isMultiLine {
+		b.WriteString(r.stackBlock(stack))
+	}
+
+	b.WriteString("</div>\n")
+	r.body.WriteString(b.String())
+}
+
+func (r *htmlRenderer) errorSpan(logEntry *LogEntry, errorText string) string {
+	style := resolveErrorStyle(DefaultStylesKey, r.config.ErrorStyles)
+	return r.span("error-key", style, logEntry.ErrorKey+": ") +
+		r.spannedSpan("error", errorText, style, valueHighlightSpans(r.args, logEntry.ErrorKey, errorText))
+}
+
+// stackBlock renders stack trace lines as a collapsible block with the frames
+// highlighted. It starts out collapsed when it's longer than --stack-lines.
+func (r *htmlRenderer) stackBlock(stack []string) string {
+	if len(stack) == 0 {
+		return ""
+	}
+
+	style := resolveErrorStyle(ErrorStackStylesKey, r.config.ErrorStyles)
+	lines := make([]string, len(stack))
+	for i, line := range stack {
+		lines[i] = messageBlockIndent + r.spannedSpan("stack-line", line, style, stackFrameSpans(r.config, line))
+	}
+
+	open := " open"
+	if _, hidden := collapseStack(stack, r.args.StackLines); hidden > 0 {
+		open = ""
+	}
+	return fmt.Sprintf(`<details class="stack"%s><summary>%s</summary>%s</details>`, open,
+		r.span("stack-summary", style, fmt.Sprintf("%sstack trace (%d lines)", messageBlockIndent, len(stack))), strings.Join(lines, "\n"))
+}
+
+// htmlPageCSS is the fixed part of the stylesheet. The dark background matches
+// the default styles, which assume a dark terminal.
+const htmlPageCSS = `body{background:#1e1e1e;color:#d4d4d4;font-family:Menlo,Consolas,"DejaVu Sans Mono",monospace;font-size:13px;margin:0}
+header{position:sticky;top:0;background:#252526;padding:8px 12px;border-bottom:1px solid #3c3c3c}
+#filter{width:40em;max-width:90%;background:#1e1e1e;color:#d4d4d4;border:1px solid #3c3c3c;padding:4px 6px;font:inherit}
+main{padding:8px 12px}
+.entry{white-space:pre-wrap;word-break:break-word}
+.group{margin-bottom:1em}
+.group .group{margin:0 0 0 2ch}
+.group-header{cursor:pointer}
+a{color:inherit}
+.stack>summary{cursor:pointer;list-style:none}`
+
+// htmlFilterScript hides entries that don't contain the filter text, and
+// groups left without any visible entry.
+const htmlFilterScript = `const filter = document.getElementById("filter");
+filter.addEventListener("input", () => {
+  const term = filter.value.toLowerCase();
+  document.querySelectorAll(".entry").forEach((entry) => {
+    entry.hidden = term !== "" && !entry.textContent.toLowerCase().includes(term);
+  });
+  document.querySelectorAll("details.group").forEach((group) => {
+    group.hidden = term !== "" && group.querySelector(".entry:not([hidden])") === null;
+  });
+});`
+
+const htmlDocumentTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>plr</title>
+<style>
+%s
+%s
+</style>
+</head>
+<body>
+<header><input id="filter" type="search" placeholder="Filter lines…" autofocus></header>
+<main>
+%s</main>
+<script>
+%s
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStyleToCSS(t *testing.T) {
+	tests := []struct {
+		name  string
+		style *Style
+		want  string
+	}{
+		{"nil style", nil, ""},
+		{"foreground color", &Style{FgColor: strPtr("fgRed")}, "color:#cd3131"},
+		{"background color uses its foreground twin", &Style{BgColor: strPtr("bgHiBlue")}, "background-color:#3b8eea"},
+		{"unknown color is skipped", &Style{FgColor: strPtr("nope")}, ""},
+		{
+			"attributes",
+			&Style{Bold: boolPtr(true), Italic: boolPtr(true), Underline: boolPtr(true)},
+			"font-weight:bold;font-style:italic;text-decoration:underline",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := styleToCSS(tt.style); got != tt.want {
+				t.Errorf("styleToCSS() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLStyleSheet_SharesClassForIdenticalStyles(t *testing.T) {
+	sheet := newHTMLStyleSheet()
+
+	a := sheet.classFor(&Style{FgColor: strPtr("fgRed")})
+	b := sheet.classFor(&Style{FgColor: strPtr("fgRed")})
+	c := sheet.classFor(&Style{FgColor: strPtr("fgGreen")})
+
+	if a != b {
+		t.Errorf("identical styles got classes %q and %q, want the same", a, b)
+	}
+	if a == c {
+		t.Errorf("different styles share class %q", a)
+	}
+	if got := sheet.classFor(nil); got != "" {
+		t.Errorf("classFor(nil) = %q, want empty", got)
+	}
+}
+
+func TestWriteHTMLDocument(t *testing.T) {
+	config := *newDefaultConfig()
+
+	entries := []*LogEntry{
+		{LineNumber: 1, PodID: "api-7d8f9b6c5-x2k4p", Time: "2026-06-25T12:00:01Z", Level: "error", Message: "boom <b>", Fields: map[string]string{"trace.id": "abc"}, IsParsed: true},
+		{LineNumber: 2, OriginalLogLine: []byte("not json & such")},
+	}
+
+	t.Run("renders a self-contained page with escaped, styled entries", func(t *testing.T) {
+		var out strings.Builder
+		if err := writeHTMLDocument(&out, Args{}, config, entries, newPodColorizer(nil), nil); err != nil {
+			t.Fatalf("writeHTMLDocument() error = %v", err)
+		}
+		got := out.String()
+
+		for _, want := range []string{
+			"<!DOCTYPE html>",
+			`id="filter"`,
+			"boom &lt;b&gt;",
+			"not json &amp; such",
+			`class="pod `,
+			`data-level="error"`,
+			">trace.id</span>=[",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("document does not contain %q", want)
+			}
+		}
+		if strings.Contains(got, "\x1b[") {
+			t.Errorf("document contains ANSI escape codes")
+		}
+	})
+
+	t.Run("starts fields on their own line after a block message", func(t *testing.T) {
+		var out strings.Builder
+		args := Args{MessageLayout: messageLayoutBlock}
+		blockEntries := []*LogEntry{
+			{LineNumber: 1, Time: "2026-06-25T12:00:01Z", Level: "info", Message: "Config:\nServe: 8080", Fields: map[string]string{"a": "1"}, IsParsed: true},
+		}
+		if err := writeHTMLDocument(&out, args, config, blockEntries, nil, nil); err != nil {
+			t.Fatalf("writeHTMLDocument() error = %v", err)
+		}
+		got := out.String()
+
+		if !strings.Contains(got, "Serve: 8080</span>\n"+messageBlockIndent+`<span class="key`) {
+			t.Errorf("fields don't start on their own indented line after the message")
+		}
+		if strings.Contains(got, "8080</span> - ") {
+			t.Errorf("fields are appended to the last message line")
+		}
+	})
+
+	t.Run("renders groups as collapsible sections", func(t *testing.T) {
+		var out strings.Builder
+		args := Args{GroupBy: [][]string{{"trace.id"}}}
+		if err := writeHTMLDocument(&out, args, config, entries, nil, nil); err != nil {
+			t.Fatalf("writeHTMLDocument() error = %v", err)
+		}
+		got := out.String()
+
+		if !strings.Contains(got, `<details class="group" open>`) {
+			t.Errorf("document has no group section")
+		}
+		if !strings.Contains(got, "══ trace.id=abc · 1 line · 1 error · 1 pod · api ══") {
+			t.Errorf("document has no group header for trace abc")
+		}
+		if !strings.Contains(got, "══ ungrouped · 1 line ══") {
+			t.Errorf("document has no ungrouped section")
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// LineStyleRule styles a whole log entry when its condition matches, e.g.
+// {"when": "level>=error", "style": {"bgColor": "bgRed"}}. Rules are composed
+// with the per-segment styles: the line style fills in whatever a segment's own
+// style leaves unset (typically the background), and MessageStyle is layered
+// over the message's style.
+type LineStyleRule struct {
+	// When is a condition such as "level>=error" or "http.status>=500", see
+	// parseCondition.
+	When string
+	// Style applies to every segment of the line.
+	Style *Style
+	// MessageStyle applies to the message only.
+	MessageStyle *Style
+
+	condition *Condition
+}
+
+// compileLineStyles parses the rules' conditions once, when the config is
+// loaded. Rules that fail to parse are dropped and described in problems.
+func compileLineStyles(rules []LineStyleRule) (compiled []LineStyleRule, problems []string) {
+	for i, rule := range rules {
+		condition, err := parseCondition(rule.When)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("LineStyles[%d]: %v", i, err))
+			continue
+		}
+		rule.condition = condition
+		compiled = append(compiled, rule)
+	}
+	return compiled, problems
+}
+
+// matchLineStyles returns the line and message styles of every rule matching
+// the entry merged in order, so later rules win. Both are nil if no rule matches.
+func matchLineStyles(config Config, logEntry *LogEntry) (lineStyle, messageStyle *Style) {
+	for _, rule := range config.LineStyles {
+		if !rule.condition.Matches(logEntry, config.LogLevelToSeverity) {
+			continue
+		}
+		lineStyle = mergeStyles(lineStyle, rule.Style)
+		messageStyle = mergeStyles(messageStyle, rule.MessageStyle)
+	}
+	return lineStyle, messageStyle
+}
+
+// mergeStyles returns base with every attribute overlay sets replacing base's.
+func mergeStyles(base, overlay *Style) *Style {
+	if overlay == nil {
+		return base
+	}
+	if base == nil {
+		merged := *overlay
+		return &merged
+	}
+
+	merged := *base
+	if overlay.FgColor != nil {
+		merged.FgColor = overlay.FgColor
+	}
+	if overlay.BgColor != nil {
+		merged.BgColor = overlay.BgColor
+	}
+	if overlay.Bold != nil {
+		merged.Bold = overlay.Bold
+	}
+	if overlay.Underline != nil {
+		merged.Underline = overlay.Underline
+	}
+	if overlay.Italic != nil {
+		merged.Italic = overlay.Italic
+	}
+	return &merged
+}
+
+// styleSGR returns the escape sequence that switches on style, or "" when the
+// style is empty or colors are disabled.
+func styleSGR(style *Style) string {
+	if style == nil || color.NoColor {
+		return ""
+	}
+
+	attributes := styleAttributes(style)
+	if len(attributes) == 0 {
+		return ""
+	}
+
+	params := make([]string, len(attributes))
+	for i, attribute := range attributes {
+		params[i] = strconv.Itoa(int(attribute))
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// applyLineStyle styles every line of text with style. Segments keep their own
+// styles: the line style is switched on again after each reset they end with,
+// so it only shows where a segment doesn't override it.
+func applyLineStyle(text string, style *Style) string {
+	sgr := styleSGR(style)
+	if sgr == "" {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, ansiReset, ansiReset+sgr)
+		line = strings.ReplaceAll(line, "\x1b[m", "\x1b[m"+sgr)
+		lines[i] = sgr + line + ansiReset
+	}
+	return strings.Join(lines, "\n")
+}
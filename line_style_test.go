@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestMatchLineStyles(t *testing.T) {
+	config := *newDefaultConfig()
+	rules, problems := compileLineStyles([]LineStyleRule{
+		{When: "level>=error", Style: &Style{BgColor: strPtr("bgRed")}},
+		{When: "http.status>=500", MessageStyle: &Style{Bold: boolPtr(true)}},
+		{When: "level=fatal", Style: &Style{BgColor: strPtr("bgMagenta")}},
+		{When: "nonsense"},
+	})
+	if len(problems) != 1 || len(rules) != 3 {
+		t.Fatalf("compileLineStyles() = %d rules, problems %v; want 3 rules and 1 problem", len(rules), problems)
+	}
+	config.LineStyles = rules
+
+	t.Run("merges every matching rule, later rules winning", func(t *testing.T) {
+		lineStyle, messageStyle := matchLineStyles(config, &LogEntry{Level: "fatal", Fields: map[string]string{"http.status": "502"}})
+
+		if lineStyle == nil || *lineStyle.BgColor != "bgMagenta" {
+			t.Errorf("line style = %+v, want bgMagenta", lineStyle)
+		}
+		if messageStyle == nil || !*messageStyle.Bold {
+			t.Errorf("message style = %+v, want bold", messageStyle)
+		}
+	})
+
+	t.Run("returns nil styles when no rule matches", func(t *testing.T) {
+		lineStyle, messageStyle := matchLineStyles(config, &LogEntry{Level: "info", Fields: map[string]string{}})
+		if lineStyle != nil || messageStyle != nil {
+			t.Errorf("matchLineStyles() = %+v, %+v, want nil", lineStyle, messageStyle)
+		}
+	})
+}
+
+func TestApplyLineStyle(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+
+	style := &Style{BgColor: strPtr("bgRed")}
+
+	color.NoColor = false
+	got := applyLineStyle("a \x1b[32mb\x1b[0m c\nd", style)
+	want := "\x1b[41ma \x1b[32mb\x1b[0m\x1b[41m c\x1b[0m\n\x1b[41md\x1b[0m"
+	if got != want {
+		t.Errorf("applyLineStyle() = %q, want %q", got, want)
+	}
+
+	color.NoColor = true
+	if got := applyLineStyle("plain", style); got != "plain" {
+		t.Errorf("applyLineStyle() with colors off = %q, want it unchanged", got)
+	}
+}
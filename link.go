@@ -0,0 +1,183 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --hyperlinks modes.
+const (
+	hyperlinksAuto   = "auto"
+	hyperlinksAlways = "always"
+	hyperlinksNever  = "never"
+)
+
+// hyperlinksEnabled is set by applyHyperlinkPolicy. Links are written as plain
+// text while it's off.
+var hyperlinksEnabled bool
+
+// osc8Close ends an OSC 8 hyperlink.
+const osc8Close = "\x1b]8;;\x1b\\"
+
+// linkTemplatePlaceholder matches {{name}} in a link template.
+var linkTemplatePlaceholder = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// linkFieldAliases are placeholders that are short for a field, so templates
+// for ECS source locations can say {{file}}:{{line}}.
+var linkFieldAliases = map[string]string{
+	"file": "log.origin.file.name",
+	"line": "log.origin.file.line",
+}
+
+// hyperlinksWanted decides whether to write OSC 8 hyperlinks. --hyperlinks
+// always and never are final. In auto mode links are written only to a
+// terminal known to support them, since others may print the escape codes.
+func hyperlinksWanted(mode string, getenv func(string) string, isTerminal bool) bool {
+	switch mode {
+	case hyperlinksAlways:
+		return true
+	case hyperlinksNever:
+		return false
+	}
+	return isTerminal && getenv("TERM") != "dumb" && terminalSupportsHyperlinks(getenv)
+}
+
+// terminalSupportsHyperlinks recognises terminals with OSC 8 support from the
+// environment variables they set.
+func terminalSupportsHyperlinks(getenv func(string) string) bool {
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "Hyper", "ghostty", "Tabby":
+		return true
+	}
+
+	if getenv("WT_SESSION") != "" || getenv("KITTY_WINDOW_ID") != "" || getenv("KONSOLE_VERSION") != "" || getenv("DOMTERM") != "" {
+		return true
+	}
+
+	// VTE based terminals (GNOME Terminal, Tilix, ...) support OSC 8 since 0.50.
+	if version, err := strconv.Atoi(getenv("VTE_VERSION")); err == nil && version >= 5000 {
+		return true
+	}
+
+	term := getenv("TERM")
+	for _, name := range []string{"kitty", "alacritty", "foot", "ghostty", "wezterm"} {
+		if strings.Contains(term, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyHyperlinkPolicy sets the process-wide hyperlink policy for --hyperlinks.
+func applyHyperlinkPolicy(mode string) {
+	hyperlinksEnabled = hyperlinksWanted(mode, os.Getenv, stdoutIsTerminal())
+	logDebug("Hyperlinks enabled: %t (--hyperlinks=%s)\n", hyperlinksEnabled, mode)
+}
+
+// findLinkTemplate returns the Links template for a field: the one for its
+// exact name, else the first wildcard key (in sorted order) matching it.
+func findLinkTemplate(links map[string]string, fieldName string) (string, bool) {
+	if template, ok := links[fieldName]; ok {
+		return template, true
+	}
+	for _, key := range sortedKeys(links) {
+		if strings.Contains(key, "*") && matchesHighlight(fieldName, key) {
+			return links[key], true
+		}
+	}
+	return "", false
+}
+
+// fieldLinkURL builds the URL a field value links to from the field's Links
+// template. {{value}} is the field's value, and any other placeholder is a
+// LinkVariables entry, a field of the same entry, or one of linkFieldAliases.
+// No URL is built when a placeholder can't be filled in.
+func fieldLinkURL(config Config, logEntry *LogEntry, fieldName string) (string, bool) {
+	template, ok := findLinkTemplate(config.Links, fieldName)
+	if !ok {
+		return "", false
+	}
+
+	return expandLinkTemplate(template, func(name string) (string, bool) {
+		if name == "value" {
+			return logEntry.Fields[fieldName], true
+		}
+		if value, ok := config.LinkVariables[name]; ok {
+			return value, true
+		}
+		if value, ok := logEntry.Fields[name]; ok {
+			return value, true
+		}
+		if field, ok := linkFieldAliases[name]; ok {
+			value, ok := logEntry.Fields[field]
+			return value, ok
+		}
+		return "", false
+	})
+}
+
+// expandLinkTemplate fills in the template's placeholders with lookup,
+// escaping each value for where it's placed: as a query value after a "?",
+// else as a path, keeping its "/" so file paths stay intact. It fails when a
+// placeholder is unknown or a value holds control characters.
+func expandLinkTemplate(template string, lookup func(name string) (string, bool)) (string, bool) {
+	var b strings.Builder
+	ok := true
+	end := 0
+
+	for _, match := range linkTemplatePlaceholder.FindAllStringSubmatchIndex(template, -1) {
+		b.WriteString(template[end:match[0]])
+		end = match[1]
+
+		value, found := lookup(template[match[2]:match[3]])
+		if !found || strings.IndexFunc(value, isControlRune) >= 0 {
+			ok = false
+		}
+
+		before := template[:match[0]]
+		if strings.Contains(before, "?") && !strings.Contains(before, "#") {
+			b.WriteString(url.QueryEscape(value))
+		} else {
+			b.WriteString(escapeLinkPath(value))
+		}
+	}
+	b.WriteString(template[end:])
+
+	link := b.String()
+	return link, ok && link != ""
+}
+
+// escapeLinkPath escapes a value placed in the path (or fragment) of a link,
+// segment by segment.
+func escapeLinkPath(value string) string {
+	segments := strings.Split(value, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func isControlRune(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+// hyperlink wraps text in an OSC 8 hyperlink to url, or returns it unchanged
+// when hyperlinks are off.
+func hyperlink(url, text string) string {
+	if !hyperlinksEnabled || url == "" {
+		return text
+	}
+	return "\x1b]8;;" + url + "\x1b\\" + text + osc8Close
+}
+
+// linkFieldValue links a rendered field value to the URL from its Links
+// template, if it has one.
+func linkFieldValue(config Config, logEntry *LogEntry, fieldName, text string) string {
+	if url, ok := fieldLinkURL(config, logEntry, fieldName); ok {
+		return hyperlink(url, text)
+	}
+	return text
+}
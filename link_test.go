@@ -0,0 +1,103 @@
+package main
+
+import "testing"
+
+func TestHyperlinksWanted(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	tests := []struct {
+		name       string
+		mode       string
+		env        map[string]string
+		isTerminal bool
+		want       bool
+	}{
+		{"always", hyperlinksAlways, nil, false, true},
+		{"never", hyperlinksNever, map[string]string{"TERM_PROGRAM": "iTerm.app"}, true, false},
+		{"auto in a supporting terminal", hyperlinksAuto, map[string]string{"TERM_PROGRAM": "WezTerm"}, true, true},
+		{"auto in a recent VTE terminal", hyperlinksAuto, map[string]string{"VTE_VERSION": "7200"}, true, true},
+		{"auto in an old VTE terminal", hyperlinksAuto, map[string]string{"VTE_VERSION": "4800"}, true, false},
+		{"auto in an unknown terminal", hyperlinksAuto, map[string]string{"TERM": "xterm-256color"}, true, false},
+		{"auto when redirected", hyperlinksAuto, map[string]string{"TERM": "xterm-kitty"}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hyperlinksWanted(tt.mode, env(tt.env), tt.isTerminal); got != tt.want {
+				t.Errorf("hyperlinksWanted() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldLinkURL(t *testing.T) {
+	config := *newDefaultConfig()
+	config.Links = map[string]string{
+		"trace.id":             "https://tracing.local/trace/{{value}}",
+		"log.origin.file.name": "vscode://file/{{repo}}/{{file}}:{{line}}",
+		"user.*":               "https://admin.local/users/{{ value }}",
+		"span.id":              "https://tracing.local/trace/{{trace.id}}?span={{value}}",
+		"search":               "https://logs.local/q/{{value}}?q={{value}}#{{value}}",
+	}
+	config.LinkVariables = map[string]string{"repo": "/src/booking"}
+
+	entry := &LogEntry{Fields: map[string]string{
+		"trace.id":             "abc123",
+		"span.id":              "s1",
+		"user.id":              "42",
+		"log.origin.file.name": "main.go",
+		"log.origin.file.line": "12",
+		"plain":                "x",
+		"search":               "a b?c#d&e/f",
+	}}
+
+	tests := []struct {
+		field  string
+		want   string
+		wantOK bool
+	}{
+		{"trace.id", "https://tracing.local/trace/abc123", true},
+		{"log.origin.file.name", "vscode://file//src/booking/main.go:12", true},
+		{"user.id", "https://admin.local/users/42", true},
+		{"span.id", "https://tracing.local/trace/abc123?span=s1", true},
+		{"search", "https://logs.local/q/a%20b%3Fc%23d&e/f?q=a+b%3Fc%23d%26e%2Ff#a%20b%3Fc%23d&e/f", true},
+		{"plain", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got, ok := fieldLinkURL(config, entry, tt.field)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("fieldLinkURL() = %q, %t, want %q, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	t.Run("no link when a placeholder is missing", func(t *testing.T) {
+		config.LinkVariables = nil
+		if got, ok := fieldLinkURL(config, entry, "log.origin.file.name"); ok {
+			t.Errorf("fieldLinkURL() = %q, want no link without {{repo}}", got)
+		}
+	})
+}
+
+func TestHyperlink(t *testing.T) {
+	defer func(enabled bool) { hyperlinksEnabled = enabled }(hyperlinksEnabled)
+
+	hyperlinksEnabled = false
+	if got := hyperlink("https://example.com", "abc"); got != "abc" {
+		t.Errorf("hyperlink() with hyperlinks off = %q, want plain text", got)
+	}
+
+	hyperlinksEnabled = true
+	got := hyperlink("https://example.com", "abc")
+	want := "\x1b]8;;https://example.com\x1b\\abc\x1b]8;;\x1b\\"
+	if got != want {
+		t.Errorf("hyperlink() = %q, want %q", got, want)
+	}
+	if width := visibleWidth(got); width != 3 {
+		t.Errorf("visibleWidth(hyperlink) = %d, want 3", width)
+	}
+}
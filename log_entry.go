@@ -9,11 +9,27 @@ type LogEntry struct {
 	LineNumber      int
 	OriginalLogLine []byte
 	PodID           string
+	App             string
 	Time            string
 	Level           string
 	Message         string
 	Fields          map[string]string
-	IsParsed        bool
+	// FieldPaths holds the object path each field had in the original JSON,
+	// e.g. ["http", "request", "method"] for http.request.method when nested but
+	// ["http.request.method"] when the key arrived pre-dotted. Fields without
+	// an entry are treated as a single segment.
+	FieldPaths map[string][]string
+	// SourceOrder lists the flattened keys in the order they appeared in the
+	// original JSON. It is only recorded when FieldOrder sorts by source.
+	SourceOrder []string
+	// Error and Stack hold the values of the fields matching ErrorKeywords and
+	// StackKeywords, and ErrorKey and StackKey those fields' names. The fields
+	// stay in Fields too, so filters, grouping and columns still find them.
+	Error    string
+	ErrorKey string
+	Stack    string
+	StackKey string
+	IsParsed bool
 }
 
 func (l *LogEntry) setFromJsonMap(logMap map[string]interface{}, keywords KeywordConfig) {
@@ -22,11 +38,26 @@ func (l *LogEntry) setFromJsonMap(logMap map[string]interface{}, keywords Keywor
 	// Keyword matching then runs against the flattened names, so a keyword like
 	// the ECS "log.level" is recognised whether it arrives nested or pre-dotted.
 	flat := make(map[string]string, len(logMap))
-	flattenJSON(flat, "", logMap)
+	paths := make(map[string][]string, len(logMap))
+	flattenJSON(flat, paths, nil, logMap)
+
+	if l.FieldPaths == nil {
+		l.FieldPaths = make(map[string][]string, len(flat))
+	}
+
+	errorRank, stackRank := -1, -1
 
 	for key, value := range flat {
 		lowerKey := strings.ToLower(key)
 
+		// The error and stack are kept as fields as well. When several fields
+		// match, the keyword listed first wins.
+		if rank := keywordIndex(lowerKey, keywords.ErrorKeywords); rank >= 0 && (errorRank < 0 || rank < errorRank) {
+			l.Error, l.ErrorKey, errorRank = value, key, rank
+		} else if rank := keywordIndex(lowerKey, keywords.StackKeywords); rank >= 0 && (stackRank < 0 || rank < stackRank) {
+			l.Stack, l.StackKey, stackRank = value, key, rank
+		}
+
 		if matchesAnyKeyword(lowerKey, keywords.LevelKeywords) {
 			l.Level = value
 			continue
@@ -43,6 +74,7 @@ func (l *LogEntry) setFromJsonMap(logMap map[string]interface{}, keywords Keywor
 		}
 
 		l.Fields[key] = value
+		l.FieldPaths[key] = paths[key]
 	}
 
 	l.IsParsed = true
@@ -51,20 +83,22 @@ func (l *LogEntry) setFromJsonMap(logMap map[string]interface{}, keywords Keywor
 // flattenJSON recursively flattens a decoded JSON object into dst, joining
 // nested object keys with dots. Non-object values (scalars, arrays, null) are
 // stored as their default string form, which preserves how arrays and scalars
-// were rendered before recursive flattening was introduced.
-func flattenJSON(dst map[string]string, prefix string, m map[string]interface{}) {
+// were rendered before recursive flattening was introduced. The object path of
+// every flattened key is recorded in paths so the hierarchy can be rebuilt.
+func flattenJSON(dst map[string]string, paths map[string][]string, path []string, m map[string]interface{}) {
 	for key, value := range m {
-		fullKey := key
-		if prefix != "" {
-			fullKey = prefix + "." + key
-		}
+		keyPath := make([]string, len(path), len(path)+1)
+		copy(keyPath, path)
+		keyPath = append(keyPath, key)
+		fullKey := strings.Join(keyPath, ".")
 
 		if nested, ok := value.(map[string]interface{}); ok {
-			flattenJSON(dst, fullKey, nested)
+			flattenJSON(dst, paths, keyPath, nested)
 			continue
 		}
 
 		dst[fullKey] = fmt.Sprintf("%v", value)
+		paths[fullKey] = keyPath
 	}
 }
 
@@ -79,6 +113,17 @@ func matchesAnyKeyword(lowerKey string, keywords []string) bool {
 	return false
 }
 
+// keywordIndex returns the position of the (already lower-cased) field name in
+// keywords, or -1 if it isn't one of them.
+func keywordIndex(lowerKey string, keywords []string) int {
+	for i, keyword := range keywords {
+		if lowerKey == keyword {
+			return i
+		}
+	}
+	return -1
+}
+
 func (l *LogEntry) setOriginalLogLine(line []byte) {
 	copy(l.OriginalLogLine, line)
 	l.IsParsed = false
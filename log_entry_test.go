@@ -1,6 +1,9 @@
 package main
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func newTestEntry() *LogEntry {
 	return &LogEntry{Fields: make(map[string]string)}
@@ -54,6 +57,22 @@ func TestSetFromJsonMap_Flattening(t *testing.T) {
 		}
 	})
 
+	t.Run("records the original object path of nested and pre-dotted fields", func(t *testing.T) {
+		entry := newTestEntry()
+
+		entry.setFromJsonMap(map[string]interface{}{
+			"http":     map[string]interface{}{"status": float64(200)},
+			"trace.id": "abc",
+		}, keywords)
+
+		if got := strings.Join(entry.FieldPaths["http.status"], "|"); got != "http|status" {
+			t.Errorf("FieldPaths[http.status] = %q, want %q", got, "http|status")
+		}
+		if got := strings.Join(entry.FieldPaths["trace.id"], "|"); got != "trace.id" {
+			t.Errorf("FieldPaths[trace.id] = %q, want %q", got, "trace.id")
+		}
+	})
+
 	t.Run("keeps a one-level field whose inner key already contains a dot", func(t *testing.T) {
 		entry := newTestEntry()
 
@@ -154,3 +173,45 @@ func TestSetFromJsonMap_Flattening(t *testing.T) {
 		}
 	})
 }
+
+func TestSetFromJsonMap_Errors(t *testing.T) {
+	keywords := *newDefaultConfig().Keywords
+
+	t.Run("fills the error and stack slots and keeps the fields", func(t *testing.T) {
+		entry := newTestEntry()
+
+		entry.setFromJsonMap(map[string]interface{}{
+			"error": "connection refused",
+			"stack": "main.main\n\tmain.go:12",
+		}, keywords)
+
+		if entry.Error != "connection refused" || entry.ErrorKey != "error" {
+			t.Errorf("Error = %q (%q), want %q (%q)", entry.Error, entry.ErrorKey, "connection refused", "error")
+		}
+		if entry.Stack != "main.main\n\tmain.go:12" || entry.StackKey != "stack" {
+			t.Errorf("Stack = %q (%q), want the stack field", entry.Stack, entry.StackKey)
+		}
+		if entry.Fields["error"] != "connection refused" {
+			t.Errorf("error should stay in Fields for filters and grouping")
+		}
+	})
+
+	t.Run("prefers the keyword listed first", func(t *testing.T) {
+		entry := newTestEntry()
+
+		entry.setFromJsonMap(map[string]interface{}{
+			"error": map[string]interface{}{
+				"message":     "timeout",
+				"stack_trace": "at Booking.java:118",
+			},
+			"stacktrace": "other",
+		}, keywords)
+
+		if entry.ErrorKey != "error.message" {
+			t.Errorf("ErrorKey = %q, want %q", entry.ErrorKey, "error.message")
+		}
+		if entry.StackKey != "stacktrace" {
+			t.Errorf("StackKey = %q, want %q", entry.StackKey, "stacktrace")
+		}
+	})
+}
@@ -11,6 +11,7 @@ import (
 )
 
 var multiLine = flag.Bool("multi-line", false, "Print output on multiple lines with log message and level first and then each field/data-entry on separate lines")
+var treeFields = flag.Bool("tree", false, "Print data fields as an indented tree of the original JSON objects instead of flattened dotted names. Implies --multi-line")
 var noData = flag.Bool("no-data", false, "Don't show data fields (additional key-value pairs of arbitrary data)")
 var levelFilter = flag.String("level", "", "Only show log messages with matching level. Values (logrus levels): trace|debug|info|warning|error|fatal|panic")
 var fieldsFilter = flag.String("fields", "", "Only show specific data fields separated by comma")
@@ -18,13 +19,34 @@ var exceptFieldsFilter = flag.String("except", "", "Don't show this particular f
 var truncateFlag = flag.String("trunc", "", "Truncate the content of this field by x number of characters. Example: --trunc message=50")
 var whereFlag = flag.String("where", "", "Filter log entries based on a condition. Example: --where trace.id=abc")
 var debugFlag = flag.Bool("debug", false, "Print verbose debug information")
-var highlightKey = flag.String("highlight-key", "", "Highlight the specified key in the output")
-var highlightValue = flag.String("highlight-value", "", "Highlight the specified value in the output")
+var highlightKeys repeatedFlag
+var highlightValues repeatedFlag
 var minLevelFilter = flag.String("min-level", "", "Only show log messages with this level or higher")
 var maxLevelFilter = flag.String("max-level", "", "Only show log messages with this level or lower")
 var allFields = flag.Bool("all-fields", false, "Show all fields, including excluded ones from config file")
 var noPodID = flag.Bool("no-pod-id", false, "Don't prepend the pod ID to each line when reading kubectl logs fetched with --prefix (e.g. kubectl logs -l <selector> --prefix)")
-var groupByFlag = flag.String("group-by", "", "Group log lines by the value of a field (e.g. --group-by trace.id), printing each group together under a header. Accepts a comma-separated fallback list treated as one logical key (e.g. --group-by trace.id,labels.trace.id). Batch mode: reads to end of input, so not for use with kubectl logs -f")
+var widthFlag = flag.String("width", widthAuto, "Wrap lines at this many columns, indenting continuation lines under the message. auto uses the terminal width when printing to a terminal; 0 disables wrapping")
+var colorFlag = flag.String("color", colorAuto, "When to color the output: auto|always|never. auto colors only when printing to a terminal and honors NO_COLOR, FORCE_COLOR and CLICOLOR_FORCE. Use always when piping to less -R")
+var hyperlinksFlag = flag.String("hyperlinks", hyperlinksAuto, "When to make field values with a Links template in the config file clickable (OSC 8 hyperlinks): auto|always|never. auto only writes links to terminals known to support them")
+var themeFlag = flag.String("theme", "", "Color theme: dark|light|solarized|high-contrast or a theme defined in the config file's Themes. Styles set in the config file are applied on top. Default from PRETTY_LOGRUS_THEME or config Theme")
+var outputFlag = flag.String("output", outputText, "Output format: text|html|csv|tsv. html renders a self-contained HTML page with the same styling, e.g. plr --output html > logs.html")
+var columnsFlag = flag.String("columns", "", "Columns to include with --output csv|tsv, separated by comma. Besides field names, time, level, pod, message and line are available, and * expands to every field name seen (batch mode). Default: time,level,pod,message,*")
+var noHeader = flag.Bool("no-header", false, "Don't write a header row with --output csv|tsv")
+var timeFormatFlag = flag.String("time-format", "", "How to display timestamps: raw (as logged)|local|utc|relative|delta|elapsed, or a Go time layout such as 15:04:05.000, optionally prefixed by local or utc (e.g. \"local 15:04:05\"). Default from config TimeFormat")
+var messageLayoutFlag = flag.String("message-layout", "", "How to display messages spanning several lines: block (first line inline, the rest indented below it and the fields on their own line after it)|escape (one line, showing line breaks as \\n)|raw. Default from config MessageLayout for the output mode")
+var stackLinesFlag = flag.String("stack-lines", "", "Collapse stack traces to this many lines, with a note of how many were left out. 0 shows the whole stack trace. Default from config StackTraceLines")
+var groupByFlag = flag.String("group-by", "", "Group log lines by the value of a field (e.g. --group-by trace.id), printing each group together under a header. Accepts a comma-separated fallback list treated as one logical key (e.g. --group-by trace.id,labels.trace.id), and nested levels separated by / (e.g. --group-by service.name/trace.id). Batch mode: reads to end of input unless --group-idle or --group-end is given")
+var groupIdleFlag = flag.String("group-idle", "", "Stream --group-by output: print a group once no line for it arrived within this duration (e.g. 5s), so it can be used with kubectl logs -f")
+var groupEndFlag = flag.String("group-end", "", "Stream --group-by output: print a group as soon as a line matches this condition (e.g. \"message~request finished\"), using the same syntax as LineStyles conditions")
+var waterfallFlag = flag.Bool("waterfall", false, "With --group-by, nest each group's lines under their spans (span.id/parent.id or span_id/parent_span_id, see Keywords in the config file), with each span indented under its parent and a bar showing when it ran within the trace")
+var groupWhereFlag = flag.String("group-where", "", "Only show --group-by groups matching this condition, e.g. \"level>=error\" for traces with an error, \"app=booking-api\" or \"group.duration>2s\". Written like LineStyles conditions; a clause matches when any line in the group does")
+var groupMinLevelFlag = flag.String("group-min-level", "", "Only show --group-by groups with at least one line of this level or higher, showing the whole group")
+var groupSortFlag = flag.String("group-sort", "", "Order --group-by groups by start|duration|size|errors|key, optionally followed by :asc or :desc (e.g. duration:desc). duration, size and errors sort descending by default, start and key ascending. Default: start")
+var groupLimitFlag = flag.Int("group-limit", 0, "Only show the first this many --group-by groups, after --group-sort, with a note of how many were left out. 0 shows every group")
+var groupMaxLinesFlag = flag.Int("group-max-lines", defaultGroupMaxLines, "When streaming --group-by output, the most lines held back before the oldest groups are printed early")
+var statsFlag = flag.Bool("stats", false, "After the lines, print a report of the lines passing the filters: line counts, lines per level, pod and app, the time span covered, lines per second, and the most frequent messages and field names. With --output html|csv|tsv the report goes to stderr")
+var statsOnlyFlag = flag.Bool("stats-only", false, "Print only the --stats report, not the lines")
+var statsTopFlag = flag.Int("stats-top", defaultStatsTop, "How many of the most frequent messages and field names the --stats report lists. 0 leaves them out")
 
 var flagAliases = map[string]string{
 	"multi-line":      "M",
@@ -37,6 +59,11 @@ var flagAliases = map[string]string{
 	"highlight-value": "V",
 }
 
+func init() {
+	flag.Var(&highlightKeys, "highlight-key", "Highlight the specified key in the output. Repeat the flag to highlight several keys, each in its own color")
+	flag.Var(&highlightValues, "highlight-value", "Highlight the specified value in the output. Repeat the flag to highlight several values, each in its own color")
+}
+
 func applyFlagAliases() {
 	for long, short := range flagAliases {
 		flagSet := flag.Lookup(long)
@@ -58,12 +85,20 @@ func main() {
 
 	config := getConfig()
 
-	args, err := parseArgs(config.LogLevelToSeverity)
+	if err := applyTheme(config, parseThemeArg(*config)); err != nil {
+		fmt.Printf("Error applying theme: %v\n", err)
+		return
+	}
+
+	args, err := parseArgs(*config)
 	if err != nil {
 		fmt.Printf("Error parsing arguments: %v\n", err)
 		return
 	}
 
+	applyColorPolicy(args.Color)
+	applyHyperlinkPolicy(args.Hyperlinks)
+
 	ctx := context.Background()
 	logEntryCh := make(chan *LogEntry, 1)
 
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Message layouts decide how a message containing line breaks is displayed.
+const (
+	// messageLayoutBlock prints the first line of the message inline and the
+	// remaining lines as an indented block below it, followed by the fields on
+	// a line of their own.
+	messageLayoutBlock = "block"
+	// messageLayoutEscape keeps the message on one line, showing line breaks
+	// and tabs as \n, \r and \t.
+	messageLayoutEscape = "escape"
+	// messageLayoutRaw prints the message as logged.
+	messageLayoutRaw = "raw"
+)
+
+// Output modes a message layout can be set for in the config's MessageLayout.
+const (
+	messageLayoutModeText      = "text"
+	messageLayoutModeMultiLine = "multi-line"
+	messageLayoutModeHTML      = "html"
+	messageLayoutModeCSV       = "csv"
+)
+
+var messageLayoutModes = []string{messageLayoutModeText, messageLayoutModeMultiLine, messageLayoutModeHTML, messageLayoutModeCSV}
+
+// messageBlockIndent indents the lines of a message after the first.
+const messageBlockIndent = "    "
+
+// messageTabSpaces is what tabs in block messages are expanded to.
+const messageTabSpaces = "    "
+
+var messageEscaper = strings.NewReplacer("\r", `\r`, "\n", `\n`, "\t", `\t`)
+
+func defaultMessageLayouts() map[string]string {
+	return map[string]string{
+		messageLayoutModeText:      messageLayoutBlock,
+		messageLayoutModeMultiLine: messageLayoutBlock,
+		messageLayoutModeHTML:      messageLayoutBlock,
+		// CSV quotes cells with line breaks, so spreadsheets read them fine.
+		messageLayoutModeCSV: messageLayoutRaw,
+	}
+}
+
+func isMessageLayout(layout string) bool {
+	switch layout {
+	case messageLayoutBlock, messageLayoutEscape, messageLayoutRaw:
+		return true
+	}
+	return false
+}
+
+// validateMessageLayouts describes the config's MessageLayout entries that
+// aren't a known output mode or layout.
+func validateMessageLayouts(layouts map[string]string) []string {
+	var problems []string
+	for _, mode := range sortedKeys(layouts) {
+		if !isMessageLayoutMode(mode) {
+			problems = append(problems, fmt.Sprintf("MessageLayout: unknown output mode %q, must be one of %s", mode, strings.Join(messageLayoutModes, "|")))
+			continue
+		}
+		if !isMessageLayout(layouts[mode]) {
+			problems = append(problems, fmt.Sprintf("MessageLayout.%s: unknown layout %q, must be one of block|escape|raw", mode, layouts[mode]))
+		}
+	}
+	return problems
+}
+
+func isMessageLayoutMode(mode string) bool {
+	for _, known := range messageLayoutModes {
+		if mode == known {
+			return true
+		}
+	}
+	return false
+}
+
+// messageLayoutMode returns the MessageLayout key for an --output format. TSV
+// shares the csv layout.
+func messageLayoutMode(output string, multiLine bool) string {
+	switch output {
+	case outputHTML:
+		return messageLayoutModeHTML
+	case outputCSV, outputTSV:
+		return messageLayoutModeCSV
+	}
+	if multiLine {
+		return messageLayoutModeMultiLine
+	}
+	return messageLayoutModeText
+}
+
+// splitMessage returns the lines a message is displayed as in layout, as
+// logged unless the layout is block or escape. Only the block layout gives
+// more than one line. It expands tabs, which would
+// otherwise throw off wrapping, and drops trailing empty lines so a message
+// ending in a newline doesn't leave an empty block behind.
+func splitMessage(message, layout string) []string {
+	if layout == messageLayoutEscape {
+		return []string{messageEscaper.Replace(message)}
+	}
+	if layout != messageLayoutBlock {
+		return []string{message}
+	}
+
+	lines := strings.Split(message, "\n")
+	for i, line := range lines {
+		lines[i] = strings.ReplaceAll(strings.TrimSuffix(line, "\r"), "\t", messageTabSpaces)
+	}
+	for len(lines) > 1 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// layoutMessage lays out a formatted message after head: the first line
+// inline, the rest indented below it, and trailing (e.g. the fields) after it:
+// on the same line as a single-line message, else on an indented line of its
+// own below the block. Each line is wrapped at width like a single-line entry.
+func layoutMessage(head string, messageLines []string, trailing []wrapSegment, width int) string {
+	if len(messageLines) == 1 {
+		return softWrap(head, append([]wrapSegment{{Text: messageLines[0]}}, trailing...), width)
+	}
+
+	lines := make([]string, len(messageLines), len(messageLines)+1)
+	for i, messageLine := range messageLines {
+		lineHead := messageBlockIndent
+		if i == 0 {
+			lineHead = head
+		}
+		lines[i] = softWrap(lineHead, []wrapSegment{{Text: messageLine}}, width)
+	}
+	if len(trailing) > 0 {
+		ownLine := append([]wrapSegment{{Text: trailing[0].Text}}, trailing[1:]...)
+		lines = append(lines, softWrap(messageBlockIndent, ownLine, width))
+	}
+	return strings.Join(lines, "\n")
+}
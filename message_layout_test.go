@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitMessage(t *testing.T) {
+	message := "Config:\r\n\tServe REST on: :8080\n\n"
+
+	tests := []struct {
+		layout string
+		want   []string
+	}{
+		{messageLayoutBlock, []string{"Config:", "    Serve REST on: :8080"}},
+		{messageLayoutEscape, []string{`Config:\r\n\tServe REST on: :8080\n\n`}},
+		{messageLayoutRaw, []string{message}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			if got := splitMessage(message, tt.layout); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("single line message", func(t *testing.T) {
+		if got := splitMessage("hello", messageLayoutBlock); !reflect.DeepEqual(got, []string{"hello"}) {
+			t.Errorf("splitMessage() = %q, want [\"hello\"]", got)
+		}
+	})
+}
+
+func TestLayoutMessage(t *testing.T) {
+	fields := []wrapSegment{{Sep: " - ", Text: "a=[1]"}, {Sep: " ", Text: "b=[2]"}}
+
+	t.Run("fields start on their own line after a block", func(t *testing.T) {
+		got := layoutMessage("[info] t - ", []string{"first", "second", "third"}, fields, 0)
+		want := "[info] t - first\n    second\n    third\n    a=[1] b=[2]"
+		if got != want {
+			t.Errorf("layoutMessage() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("single line", func(t *testing.T) {
+		got := layoutMessage("[info] t - ", []string{"only"}, fields, 0)
+		want := "[info] t - only - a=[1] b=[2]"
+		if got != want {
+			t.Errorf("layoutMessage() = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestMessageLayoutMode(t *testing.T) {
+	tests := []struct {
+		output    string
+		multiLine bool
+		want      string
+	}{
+		{outputText, false, messageLayoutModeText},
+		{outputText, true, messageLayoutModeMultiLine},
+		{outputHTML, true, messageLayoutModeHTML},
+		{outputCSV, false, messageLayoutModeCSV},
+		{outputTSV, false, messageLayoutModeCSV},
+	}
+
+	for _, tt := range tests {
+		if got := messageLayoutMode(tt.output, tt.multiLine); got != tt.want {
+			t.Errorf("messageLayoutMode(%q, %t) = %q, want %q", tt.output, tt.multiLine, got, tt.want)
+		}
+	}
+}
+
+func TestValidateMessageLayouts(t *testing.T) {
+	problems := validateMessageLayouts(map[string]string{
+		"text":  "escape",
+		"html":  "fancy",
+		"pager": "block",
+	})
+
+	if len(problems) != 2 {
+		t.Fatalf("validateMessageLayouts() = %q, want 2 problems", problems)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// MessageRule styles the parts of messages matching a regular expression, e.g.
+// {"pattern": "user=(\\w+)", "groups": {"1": {"bold": true}}}.
+type MessageRule struct {
+	// Pattern is a Go regular expression.
+	Pattern string
+	// Style applies to the whole match.
+	Style *Style
+	// Groups styles capture groups, keyed by group number ("1") or name. They
+	// are layered over Style.
+	Groups map[string]*Style
+
+	regexp *regexp.Regexp
+}
+
+// Built-in message rules, off by default and turned on by name with the
+// config's BuiltinMessageRules.
+const (
+	builtinRuleUUID       = "uuid"
+	builtinRuleIP         = "ip"
+	builtinRuleDuration   = "duration"
+	builtinRuleHTTPMethod = "httpMethod"
+)
+
+// builtinMessageRuleNames lists the built-in rules in the order they're tried.
+var builtinMessageRuleNames = []string{builtinRuleUUID, builtinRuleIP, builtinRuleDuration, builtinRuleHTTPMethod}
+
+var builtinMessageRules = map[string]MessageRule{
+	builtinRuleUUID: {
+		regexp: regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`),
+		Style:  &Style{FgColor: strPtr("fgMagenta")},
+	},
+	builtinRuleIP: {
+		regexp: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}(?::\d{1,5})?\b`),
+		Style:  &Style{FgColor: strPtr("fgCyan")},
+	},
+	builtinRuleDuration: {
+		// Durations as Go prints them, e.g. 250ms, 1.5s, 2m30s or 1h30m. Whole
+		// minutes and hours ("3m", "10h") and seconds of four digits or more
+		// ("1990s") read as ordinary text too often to be styled.
+		regexp: regexp.MustCompile(`\b(?:\d+h\d+m(?:\d+(?:\.\d+)?s)?|\d+h\d+(?:\.\d+)?s|\d+m\d+(?:\.\d+)?s|\d+(?:\.\d+)?(?:ns|us|µs|ms)|\d+\.\d+s|\d{1,3}s)\b`),
+		Style:  &Style{FgColor: strPtr("fgYellow")},
+	},
+	builtinRuleHTTPMethod: {
+		regexp: regexp.MustCompile(`\b(?:GET|HEAD|POST|PUT|PATCH|DELETE|OPTIONS|CONNECT|TRACE)\b`),
+		Style:  &Style{FgColor: strPtr("fgHiBlue"), Bold: boolPtr(true)},
+	},
+}
+
+// defaultBuiltinMessageRules lists every built-in rule, turned off, so the
+// default config shows which rules there are to turn on.
+func defaultBuiltinMessageRules() map[string]bool {
+	enabled := make(map[string]bool, len(builtinMessageRuleNames))
+	for _, name := range builtinMessageRuleNames {
+		enabled[name] = false
+	}
+	return enabled
+}
+
+// compileMessageRules compiles the rules' patterns once, when the config is
+// loaded. Rules that fail to compile are dropped and described in problems, as
+// are unknown built-in rule names.
+func compileMessageRules(rules []MessageRule, builtins map[string]bool) (compiled []MessageRule, problems []string) {
+	for i, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("MessageRules[%d]: invalid pattern %q: %v", i, rule.Pattern, err))
+			continue
+		}
+
+		for _, group := range sortedKeys(rule.Groups) {
+			if messageRuleGroupIndex(re, group) < 0 {
+				problems = append(problems, fmt.Sprintf("MessageRules[%d]: pattern %q has no capture group %q", i, rule.Pattern, group))
+			}
+		}
+
+		rule.regexp = re
+		compiled = append(compiled, rule)
+	}
+
+	for _, name := range sortedKeys(builtins) {
+		if _, ok := builtinMessageRules[name]; !ok {
+			problems = append(problems, fmt.Sprintf("BuiltinMessageRules: unknown rule %q", name))
+		}
+	}
+
+	return compiled, problems
+}
+
+// messageRuleGroupIndex resolves a Groups key, a group number or name, to the
+// group's index, or -1 if the pattern has no such group.
+func messageRuleGroupIndex(re *regexp.Regexp, group string) int {
+	if index, err := strconv.Atoi(group); err == nil {
+		if index >= 0 && index <= re.NumSubexp() {
+			return index
+		}
+		return -1
+	}
+	return re.SubexpIndex(group)
+}
+
+// activeMessageRules returns the compiled rules followed by the built-in ones
+// turned on in builtins. Earlier rules win where matches overlap. It's
+// resolved once, when the config is loaded.
+func activeMessageRules(rules []MessageRule, builtins map[string]bool) []MessageRule {
+	active := append([]MessageRule{}, rules...)
+	for _, name := range builtinMessageRuleNames {
+		if builtins[name] {
+			active = append(active, builtinMessageRules[name])
+		}
+	}
+	return active
+}
+
+// messageRuleSpans returns the styled spans the message rules produce for
+// message. A match overlapping one claimed by an earlier rule is skipped.
+func messageRuleSpans(config Config, message string) []textSpan {
+	var claimed, spans []textSpan
+
+	for _, rule := range config.messageRules {
+		if rule.regexp == nil {
+			continue
+		}
+
+		for _, match := range rule.regexp.FindAllStringSubmatchIndex(message, -1) {
+			start, end := match[0], match[1]
+			if start == end || overlapsAny(claimed, start, end) {
+				continue
+			}
+			claimed = append(claimed, textSpan{Start: start, End: end})
+
+			if rule.Style != nil {
+				spans = append(spans, textSpan{Start: start, End: end, Style: rule.Style})
+			}
+			for _, group := range sortedKeys(rule.Groups) {
+				index := messageRuleGroupIndex(rule.regexp, group)
+				if index < 0 || match[2*index] < 0 {
+					continue
+				}
+				spans = append(spans, textSpan{Start: match[2*index], End: match[2*index+1], Style: rule.Groups[group]})
+			}
+		}
+	}
+
+	return spans
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestMessageRuleSpans(t *testing.T) {
+	config := *newDefaultConfig()
+
+	builtins := func(names ...string) Config {
+		config := config
+		config.BuiltinMessageRules = defaultBuiltinMessageRules()
+		for _, name := range names {
+			config.BuiltinMessageRules[name] = true
+		}
+		config.messageRules = activeMessageRules(nil, config.BuiltinMessageRules)
+		return config
+	}
+
+	matches := func(config Config, message string) []string {
+		var got []string
+		for _, span := range messageRuleSpans(config, message) {
+			got = append(got, message[span.Start:span.End])
+		}
+		return got
+	}
+
+	t.Run("built-in rules are off by default", func(t *testing.T) {
+		for _, span := range messageRuleSpans(config, "GET /users from 10.0.0.1:8080 took 12.5ms") {
+			t.Errorf("unexpected span %+v", span)
+		}
+	})
+
+	t.Run("built-in rules style common tokens once turned on", func(t *testing.T) {
+		config := builtins(builtinRuleUUID, builtinRuleIP, builtinRuleDuration, builtinRuleHTTPMethod)
+
+		got := matches(config, "GET /users from 10.0.0.1:8080 took 12.5ms")
+		if strings.Join(got, "|") != "10.0.0.1:8080|12.5ms|GET" {
+			t.Errorf("spans = %v", got)
+		}
+	})
+
+	t.Run("the duration rule matches durations", func(t *testing.T) {
+		config := builtins(builtinRuleDuration)
+
+		for _, duration := range []string{"250ms", "12µs", "1.5s", "42s", "2m30s", "1h30m", "1h2m3.5s"} {
+			if got := matches(config, "took "+duration+" in total"); len(got) != 1 || got[0] != duration {
+				t.Errorf("matches in %q = %v, want [%s]", duration, got, duration)
+			}
+		}
+	})
+
+	t.Run("the duration rule doesn't match ordinary text", func(t *testing.T) {
+		config := builtins(builtinRuleDuration)
+
+		for _, message := range []string{
+			"music from the 1990s",
+			"the 3m rule for ladders",
+			"open 10h a day",
+			"sent 5msgs",
+		} {
+			if got := matches(config, message); len(got) != 0 {
+				t.Errorf("matches in %q = %v, want none", message, got)
+			}
+		}
+	})
+
+	t.Run("capture groups are styled on top of the match, earlier rules winning", func(t *testing.T) {
+		rules, problems := compileMessageRules([]MessageRule{{
+			Pattern: `user=(?P<name>\w+) ip=(\S+)`,
+			Style:   &Style{Underline: boolPtr(true)},
+			Groups:  map[string]*Style{"name": {Bold: boolPtr(true)}},
+		}}, config.BuiltinMessageRules)
+		if len(problems) > 0 {
+			t.Fatalf("compileMessageRules() problems = %v", problems)
+		}
+		config := builtins(builtinRuleIP)
+		config.messageRules = activeMessageRules(rules, config.BuiltinMessageRules)
+
+		spans := messageRuleSpans(config, "login user=bob ip=10.0.0.1")
+		if len(spans) != 2 {
+			t.Fatalf("spans = %+v, want the match and the name group only", spans)
+		}
+		if spans[1].Start != 11 || spans[1].End != 14 || !*spans[1].Style.Bold {
+			t.Errorf("group span = %+v, want bold bytes 11-14", spans[1])
+		}
+	})
+}
+
+func TestCompileMessageRulesReportsProblems(t *testing.T) {
+	_, problems := compileMessageRules([]MessageRule{
+		{Pattern: "("},
+		{Pattern: "(a)", Groups: map[string]*Style{"2": {}}},
+	}, map[string]bool{"emails": true})
+
+	if len(problems) != 3 {
+		t.Errorf("problems = %v, want 3", problems)
+	}
+}
+
+func TestRenderSpans(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	red := &Style{FgColor: strPtr("fgRed")}
+	bold := &Style{Bold: boolPtr(true)}
+	got := renderSpans("ab cd", nil, []textSpan{{Start: 0, End: 2, Style: red}, {Start: 1, End: 2, Style: bold}}, styleString)
+	want := "\x1b[31ma\x1b[0m\x1b[31;1mb\x1b[0m\x1b[m cd\x1b[0m"
+
+	if got != want {
+		t.Errorf("renderSpans() = %q, want %q", got, want)
+	}
+}
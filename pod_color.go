@@ -10,14 +10,29 @@ import "github.com/fatih/color"
 // A PodColorizer is not safe for concurrent use; it is owned by the single
 // printer goroutine.
 type PodColorizer struct {
+	// colors are the Style colors the palette was built from, for renderers
+	// that cannot use the ANSI colors directly.
+	colors   []string
 	palette  []*color.Color
 	assigned map[string]*color.Color
 	next     int
 }
 
-func newPodColorizer() *PodColorizer {
+// newPodColorizer builds a colorizer cycling through the given Style colors
+// (e.g. a theme's PodPalette), or the default palette when there are none.
+func newPodColorizer(colors []string) *PodColorizer {
+	if len(colors) == 0 {
+		colors = defaultPodPalette
+	}
+
+	palette := make([]*color.Color, 0, len(colors))
+	for _, c := range colors {
+		palette = append(palette, newColor(styleColorAttributes(c, false)...))
+	}
+
 	return &PodColorizer{
-		palette:  defaultPodPalette(),
+		colors:   colors,
+		palette:  palette,
 		assigned: make(map[string]*color.Color),
 	}
 }
@@ -35,6 +50,18 @@ func (p *PodColorizer) colorFor(podID string) *color.Color {
 	return c
 }
 
+// styleColorFor returns the Style color assigned to podID, for renderers that
+// cannot use the ANSI color directly (e.g. the HTML output maps it to CSS).
+func (p *PodColorizer) styleColorFor(podID string) string {
+	c := p.colorFor(podID)
+	for i, candidate := range p.palette {
+		if candidate == c && i < len(p.colors) {
+			return p.colors[i]
+		}
+	}
+	return ""
+}
+
 // Colorize renders the pod ID as a bracketed, colored prefix segment.
 func (p *PodColorizer) Colorize(podID string) string {
 	return p.colorFor(podID).Sprintf("[%s]", podID)
@@ -43,15 +70,13 @@ func (p *PodColorizer) Colorize(podID string) string {
 // defaultPodPalette is the set of foreground colors cycled through when
 // assigning colors to pods. Level/timestamp/message colors are deliberately
 // avoided where possible to keep the pod segment distinguishable.
-func defaultPodPalette() []*color.Color {
-	return []*color.Color{
-		color.New(color.FgHiCyan),
-		color.New(color.FgHiMagenta),
-		color.New(color.FgHiYellow),
-		color.New(color.FgHiBlue),
-		color.New(color.FgHiGreen),
-		color.New(color.FgHiRed),
-		color.New(color.FgCyan),
-		color.New(color.FgMagenta),
-	}
+var defaultPodPalette = []string{
+	"fgHiCyan",
+	"fgHiMagenta",
+	"fgHiYellow",
+	"fgHiBlue",
+	"fgHiGreen",
+	"fgHiRed",
+	"fgCyan",
+	"fgMagenta",
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"slices"
-	"sort"
 	"strings"
 )
 
@@ -14,14 +13,53 @@ func printLogEntries(ctx context.Context, args Args, config Config, logEntries <
 	// A nil colorizer disables the pod label (e.g. --no-pod-id).
 	var colorizer *PodColorizer
 	if noPodID == nil || !*noPodID {
-		colorizer = newPodColorizer()
+		colorizer = newPodColorizer(config.PodPalette)
+	}
+
+	// Like the colorizer, the time formatter carries state across entries (the
+	// previous and first timestamp for delta/elapsed timestamps).
+	timeFormatter := newTimeFormatter(args.TimeFormat)
+
+	// --stats-only prints the report in place of the lines. --stats counts the
+	// lines on their way here and prints the report once they're all printed.
+	if args.StatsOnly {
+		printStatsOnly(ctx, args, config, logEntries)
+		return
+	}
+
+	if args.Stats {
+		stats := newLogStats()
+		logEntries = collectStats(ctx, args, config, logEntries, stats)
+		defer func() {
+			fmt.Fprint(statsOutput(args), "\n"+formatStats(config, stats, args.StatsTop))
+		}()
+	}
+
+	// HTML and CSV output have their own renderers, which handle --group-by too.
+	if args.Output == outputHTML {
+		renderHTML(ctx, args, config, logEntries, colorizer, timeFormatter)
+		return
+	}
+
+	if args.Output == outputCSV || args.Output == outputTSV {
+		renderCSV(ctx, args, config, logEntries, timeFormatter)
+		return
+	}
+
+	if legend := highlightLegend(args.HighlightKeys, args.HighlightValues); legend != "" {
+		fmt.Println(legend)
 	}
 
 	// In group mode, entries cannot be printed as they arrive: a group is only
 	// complete at end of input. Buffer the (filtered) entries and render grouped
-	// once the stream closes.
+	// once the stream closes, or, when streaming, once each group goes idle or
+	// ends.
 	if len(args.GroupBy) > 0 {
-		collectAndRenderGroups(ctx, args, config, logEntries, colorizer)
+		if isGroupStreaming(args) {
+			streamAndRenderGroups(ctx, args, config, logEntries, colorizer, timeFormatter)
+		} else {
+			collectAndRenderGroups(ctx, args, config, logEntries, colorizer, timeFormatter)
+		}
 		return
 	}
 
@@ -41,7 +79,7 @@ func printLogEntries(ctx context.Context, args Args, config Config, logEntries <
 				continue
 			}
 
-			printEntry(args, config, logEntry, colorizer)
+			printEntry(args, config, logEntry, colorizer, timeFormatter)
 		}
 	}
 }
@@ -50,7 +88,7 @@ func printLogEntries(ctx context.Context, args Args, config Config, logEntries <
 // once the stream closes, groups them by the configured field(s) and renders the
 // grouped output. This trades streaming for the ability to show a whole trace
 // together, so it is intended for bounded input (kubectl logs without -f).
-func collectAndRenderGroups(ctx context.Context, args Args, config Config, logEntries <-chan *LogEntry, colorizer *PodColorizer) {
+func collectAndRenderGroups(ctx context.Context, args Args, config Config, logEntries <-chan *LogEntry, colorizer *PodColorizer, timeFormatter *TimeFormatter) {
 	var buffer []*LogEntry
 
 	for {
@@ -59,8 +97,8 @@ func collectAndRenderGroups(ctx context.Context, args Args, config Config, logEn
 			return
 		case logEntry, ok := <-logEntries:
 			if !ok {
-				groups, ungrouped := groupEntries(buffer, args.GroupBy)
-				renderGroups(args, config, groups, ungrouped, colorizer)
+				groups, ungrouped, hidden := groupAndFilterEntries(args, config, buffer)
+				renderGroups(args, config, groups, ungrouped, hidden, colorizer, timeFormatter)
 				return
 			}
 
@@ -78,129 +116,250 @@ func collectAndRenderGroups(ctx context.Context, args Args, config Config, logEn
 
 // printEntry renders a single log entry using the active line format, falling
 // back to the raw line when the entry could not be parsed as JSON.
-func printEntry(args Args, config Config, logEntry *LogEntry, colorizer *PodColorizer) {
+func printEntry(args Args, config Config, logEntry *LogEntry, colorizer *PodColorizer, timeFormatter *TimeFormatter) {
 	if !logEntry.IsParsed {
-		println(podPrefix(colorizer, logEntry.PodID) + string(logEntry.OriginalLogLine))
+		println(podPrefix(colorizer, podLabel(config, logEntry)) + string(logEntry.OriginalLogLine))
 		return
 	}
 
-	if multiLine != nil && *multiLine {
-		printMultiLine(args, config, logEntry, colorizer)
-	} else {
-		printSingleLine(args, config, logEntry, colorizer)
+	fmt.Println(formatEntry(args, config, logEntry, colorizer, timeFormatter))
+}
+
+// formatEntry renders a parsed log entry using the active line format.
+func formatEntry(args Args, config Config, logEntry *LogEntry, colorizer *PodColorizer, timeFormatter *TimeFormatter) string {
+	if isMultiLine() {
+		return formatMultiLine(args, config, logEntry, colorizer, timeFormatter)
 	}
+	return formatSingleLine(args, config, logEntry, colorizer, timeFormatter)
+}
+
+// isMultiLine reports whether entries are printed with one field per line,
+// which --tree implies.
+func isMultiLine() bool {
+	return (multiLine != nil && *multiLine) || isTree()
+}
+
+func isTree() bool {
+	return treeFields != nil && *treeFields
+}
+
+// formatTimestamp renders the entry's timestamp in the active --time-format,
+// using the "highlight" timestamp style for a delta above the threshold.
+func formatTimestamp(config Config, logEntry *LogEntry, timeFormatter *TimeFormatter) string {
+	text, highlight := timeFormatter.Format(logEntry)
+	if highlight {
+		return styleString(resolveTimestampHighlightStyle(config.TimestampStyles), text)
+	}
+	return applyTimestampStyle(text, config.TimestampStyles)
 }
 
 // podPrefix returns the colored, bracketed pod label (with a trailing space) to
 // prepend to a log line, or an empty string when there is no pod to label or
-// the feature is disabled (nil colorizer).
-func podPrefix(colorizer *PodColorizer, podID string) string {
-	if colorizer == nil || podID == "" {
+// the feature is disabled (nil colorizer). The label is the pod name or its app
+// name, see podLabel, and each label gets its own color.
+func podPrefix(colorizer *PodColorizer, label string) string {
+	if colorizer == nil || label == "" {
 		return ""
 	}
-	return colorizer.Colorize(podID) + " "
+	return colorizer.Colorize(label) + " "
 }
 
-func printSingleLine(args Args, config Config, logEntry *LogEntry, colorizer *PodColorizer) {
+func formatSingleLine(args Args, config Config, logEntry *LogEntry, colorizer *PodColorizer, timeFormatter *TimeFormatter) string {
 	var fields []string
 
 	addField := func(fieldName, fieldValue string) {
-		value := fmtValue(args.Truncate, fieldName, fieldValue)
-		styledFieldName := applyFieldNameStyle(fieldName, config.FieldStyles, args.HighlightKey)
-		styledFieldValue := applyFieldValueStyle(fieldName, value, config.FieldStyles, args.HighlightValue)
+		value := fmtValue(args.Truncate, config.fieldFormats, fieldName, fieldValue)
+		styledFieldName := applyFieldNameStyle(fieldName, config.FieldStyles, args.HighlightKeys)
+		styledFieldValue := linkFieldValue(config, logEntry, fieldName, formatFieldValue(args, config, fieldName, value))
 		field := fmt.Sprintf("%s=[%s]", styledFieldName, styledFieldValue)
 		fields = append(fields, field)
 	}
 
-	hasExcludedFields := false
-
-	if noData == nil || *noData == false {
-		for fieldName, fieldValue := range logEntry.Fields {
-			if len(args.IncludedFields) > 0 {
-				if isFieldInMap(args.IncludedFields, fieldName) {
-					addField(fieldName, fieldValue)
-				}
-			} else if len(args.ExcludedFields) > 0 || len(config.ExcludeFields) > 0 {
-				if (isFieldInMap(args.ExcludedFields, fieldName) || isFieldInSlice(config.ExcludeFields, fieldName)) && !args.AllFields {
-					hasExcludedFields = true
-				} else {
-					addField(fieldName, fieldValue)
-				}
-			} else {
-				addField(fieldName, fieldValue)
-			}
-		}
+	fieldNames, hasExcludedFields := selectFields(args, config, logEntry)
+	orderFieldNames(fieldNames, config.FieldOrder, logEntry)
+	fieldNames, showError, showStack := takeErrorFields(fieldNames, logEntry)
+	for _, fieldName := range fieldNames {
+		addField(fieldName, logEntry.Fields[fieldName])
 	}
 
-	prefix := podPrefix(colorizer, logEntry.PodID)
+	lineStyle, messageStyle := matchLineStyles(config, logEntry)
+	prefix := podPrefix(colorizer, podLabel(config, logEntry))
 	level := applyLevelStyle(logEntry.Level, config.LevelStyles)
-	timestamp := applyTimestampStyle(logEntry.Time, config.TimestampStyles)
-	message := applyMessageStyle(fmtMessage(args.Truncate, logEntry.Message), config.MessageStyles)
+	timestamp := formatTimestamp(config, logEntry, timeFormatter)
+	messageLines := formatMessageLines(args, config, logEntry, messageStyle)
+	errorText, stack := errorParts(args, logEntry, showError, showStack)
+
+	head := fmt.Sprintf("%s[%s] %s - ", prefix, level, timestamp)
+	var segments []wrapSegment
+	if errorText != "" {
+		segments = append(segments, wrapSegment{Sep: " - ", Text: formatError(args, config, logEntry, errorText)})
+	}
 
 	if len(fields) > 0 {
-		sortedFields := sortFieldsAlphabetically(fields)
-		fieldsString := strings.Join(sortedFields, " ")
-
 		if hasExcludedFields {
 			excludedFieldsWarning := applyExcludedFieldsWarningTextStyle(config.ExcludedFieldsWarningText, config.ExcludedFieldsWarningTextStyles)
-			fieldsString = excludedFieldsWarning + " " + fieldsString
+			fields = append([]string{excludedFieldsWarning}, fields...)
+		}
+
+		for i, field := range fields {
+			sep := " "
+			if i == 0 {
+				sep = " - "
+			}
+			segments = append(segments, wrapSegment{Sep: sep, Text: field})
 		}
+	}
 
-		fmt.Printf("%s[%s] %s - %s - %s\n", prefix, level, timestamp, message, fieldsString)
-	} else {
-		fmt.Printf("%s[%s] %s - %s\n", prefix, level, timestamp, message)
+	line := layoutMessage(head, messageLines, segments, args.Width)
+	if len(stack) > 0 {
+		line += "\n" + formatStackBlock(args, config, stack)
 	}
+	return applyLineStyle(line, lineStyle)
 }
 
-func printMultiLine(args Args, config Config, logEntry *LogEntry, colorizer *PodColorizer) {
+func formatMultiLine(args Args, config Config, logEntry *LogEntry, colorizer *PodColorizer, timeFormatter *TimeFormatter) string {
 	var fields []string
 
 	addField := func(fieldName, fieldValue string) {
-		value := fmtValue(args.Truncate, fieldName, fieldValue)
-		styledFieldName := applyFieldNameStyle(fieldName, config.FieldStyles, args.HighlightKey)
-		styledFieldValue := applyFieldValueStyle(fieldName, value, config.FieldStyles, args.HighlightValue)
-		field := fmt.Sprintf("  %s: %s", styledFieldName, styledFieldValue)
+		value := fmtValue(args.Truncate, config.fieldFormats, fieldName, fieldValue)
+		styledFieldName := applyFieldNameStyle(fieldName, config.FieldStyles, args.HighlightKeys)
+		styledFieldValue := linkFieldValue(config, logEntry, fieldName, formatFieldValue(args, config, fieldName, value))
+		field := softWrap(fmt.Sprintf("  %s: ", styledFieldName), []wrapSegment{{Text: styledFieldValue}}, args.Width)
 		fields = append(fields, field)
 	}
 
-	hasExcludedFields := false
-
-	if noData == nil || *noData == false {
-		for fieldName, fieldValue := range logEntry.Fields {
-			if len(args.IncludedFields) > 0 {
-				if isFieldInMap(args.IncludedFields, fieldName) {
-					addField(fieldName, fieldValue)
-				}
-			} else if len(args.ExcludedFields) > 0 || len(config.ExcludeFields) > 0 {
-				if (isFieldInMap(args.ExcludedFields, fieldName) || isFieldInSlice(config.ExcludeFields, fieldName)) && !args.AllFields {
-					hasExcludedFields = true
-				} else {
-					addField(fieldName, fieldValue)
-				}
-			} else {
-				addField(fieldName, fieldValue)
-			}
-		}
+	fieldNames, hasExcludedFields := selectFields(args, config, logEntry)
+	orderFieldNames(fieldNames, config.FieldOrder, logEntry)
+	fieldNames, showError, showStack := takeErrorFields(fieldNames, logEntry)
+	for _, fieldName := range fieldNames {
+		addField(fieldName, logEntry.Fields[fieldName])
 	}
 
-	prefix := podPrefix(colorizer, logEntry.PodID)
+	lineStyle, messageStyle := matchLineStyles(config, logEntry)
+	prefix := podPrefix(colorizer, podLabel(config, logEntry))
 	level := applyLevelStyle(logEntry.Level, config.LevelStyles)
-	timestamp := applyTimestampStyle(logEntry.Time, config.TimestampStyles)
-	message := applyMessageStyle(fmtMessage(args.Truncate, logEntry.Message), config.MessageStyles)
+	timestamp := formatTimestamp(config, logEntry, timeFormatter)
+	messageLines := formatMessageLines(args, config, logEntry, messageStyle)
+	errorText, stack := errorParts(args, logEntry, showError, showStack)
 
-	fmt.Printf("%s[%s] %s - %s\n", prefix, level, timestamp, message)
+	header := layoutMessage(fmt.Sprintf("%s[%s] %s - ", prefix, level, timestamp), messageLines, nil, args.Width)
+	if errorText != "" {
+		header += "\n" + softWrap("  ", []wrapSegment{{Text: formatError(args, config, logEntry, errorText)}}, args.Width)
+	}
+	if len(stack) > 0 {
+		header += "\n" + formatStackBlock(args, config, stack)
+	}
+	lines := []string{applyLineStyle(header, lineStyle)}
+
+	if isTree() {
+		if tree := formatFieldTree(args, config, logEntry, fieldNames, hasExcludedFields, lineStyle); tree != "" {
+			lines = append(lines, tree)
+		}
+		return strings.Join(lines, "\n")
+	}
 
 	if len(fields) > 0 {
-		sortedFields := sortFieldsAlphabetically(fields)
-		fieldsString := strings.Join(sortedFields, "\n")
+		fieldsString := strings.Join(fields, "\n")
 
 		if hasExcludedFields {
 			excludedFieldsWarning := "  " + applyExcludedFieldsWarningTextStyle(config.ExcludedFieldsWarningText, config.ExcludedFieldsWarningTextStyles)
 			fieldsString = excludedFieldsWarning + "\n" + fieldsString
 		}
 
-		fmt.Println(fieldsString)
+		lines = append(lines, applyLineStyle(fieldsString, lineStyle))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// formatMessageLines renders the entry's message with its style, layering the
+// message style of any matching LineStyles rule on top, and the MessageRules
+// styles over the parts of the message they match. The message is split into
+// the lines of the active message layout after truncation, so --trunc
+// message=\n leaves a single line.
+func formatMessageLines(args Args, config Config, logEntry *LogEntry, lineMessageStyle *Style) []string {
+	message := fmtMessage(args.Truncate, logEntry.Message)
+	style := mergeStyles(resolveMessageStyle(message, config.MessageStyles), lineMessageStyle)
+
+	lines := splitMessage(message, args.MessageLayout)
+	for i, line := range lines {
+		lines[i] = renderSpans(line, style, messageSpans(args, config, line), styleString)
 	}
+	return lines
+}
+
+// messageSpans returns the styled spans inside a message: MessageRules matches,
+// with --highlight-value and --where matches drawn over them.
+func messageSpans(args Args, config Config, message string) []textSpan {
+	return append(messageRuleSpans(config, message), valueHighlightSpans(args, "", message)...)
+}
+
+// formatFieldValue renders a field value with its style and the parts matching
+// --highlight-value or --where highlighted.
+func formatFieldValue(args Args, config Config, fieldName, value string) string {
+	style := resolveFieldValueStyle(fieldName, value, config.FieldStyles)
+	return renderSpans(value, style, valueHighlightSpans(args, fieldName, value), styleString)
+}
+
+// valueHighlightSpans returns the --highlight-value and --where spans in a
+// field's value, or in the message when fieldName is empty. --highlight-value
+// terms win over --where terms.
+func valueHighlightSpans(args Args, fieldName, text string) []textSpan {
+	return append(highlightSpans(args.WhereHighlights, fieldName, text), highlightSpans(args.HighlightValues, fieldName, text)...)
+}
+
+// selectFields returns the names of the entry's data fields that should be shown
+// given --no-data, --fields, --except, --all-fields and the config's
+// ExcludeFields, and whether any field was left out because it was excluded.
+func selectFields(args Args, config Config, logEntry *LogEntry) (fieldNames []string, hasExcludedFields bool) {
+	if noData != nil && *noData {
+		return nil, false
+	}
+
+	for fieldName := range logEntry.Fields {
+		if len(args.IncludedFields) > 0 {
+			if isFieldInMap(args.IncludedFields, fieldName) {
+				fieldNames = append(fieldNames, fieldName)
+			}
+		} else if len(args.ExcludedFields) > 0 || len(config.ExcludeFields) > 0 {
+			if (isFieldInMap(args.ExcludedFields, fieldName) || isFieldInSlice(config.ExcludeFields, fieldName)) && !args.AllFields {
+				hasExcludedFields = true
+			} else {
+				fieldNames = append(fieldNames, fieldName)
+			}
+		} else {
+			fieldNames = append(fieldNames, fieldName)
+		}
+	}
+
+	return fieldNames, hasExcludedFields
+}
+
+// formatFieldTree renders the entry's fields as an indented tree of the objects
+// they came from. Styles, highlights and truncation are keyed on the flattened
+// field names, exactly as in the flat output.
+func formatFieldTree(args Args, config Config, logEntry *LogEntry, fieldNames []string, hasExcludedFields bool, lineStyle *Style) string {
+	if len(fieldNames) == 0 {
+		return ""
+	}
+
+	tree := buildFieldTree(fieldNames, logEntry.FieldPaths)
+
+	formatKey := func(node *fieldTreeNode) string {
+		return styleString(resolveFieldNameStyle(node.Name, config.FieldStyles, args.HighlightKeys), node.Label)
+	}
+	formatValue := func(node *fieldTreeNode) string {
+		value := fmtValue(args.Truncate, config.fieldFormats, node.Name, logEntry.Fields[node.Name])
+		return linkFieldValue(config, logEntry, node.Name, formatFieldValue(args, config, node.Name, value))
+	}
+
+	lines := renderFieldTree(tree, "  ", formatKey, formatValue)
+	if hasExcludedFields {
+		excludedFieldsWarning := "  " + applyExcludedFieldsWarningTextStyle(config.ExcludedFieldsWarningText, config.ExcludedFieldsWarningTextStyles)
+		lines = append([]string{excludedFieldsWarning}, lines...)
+	}
+
+	return applyLineStyle(strings.Join(lines, "\n"), lineStyle)
 }
 
 func isFieldInSlice(list []string, fieldName string) bool {
@@ -283,7 +442,10 @@ func isFieldInMap(list map[string]struct{}, fieldName string) bool {
 	return false
 }
 
-func fmtValue(truncate *Truncate, key, value string) string {
+// fmtValue prepares a field value for display: formatted with its FieldFormats
+// formatter, then truncated with --trunc. Filters keep using the raw value.
+func fmtValue(truncate *Truncate, formats map[string]fieldFormat, key, value string) string {
+	value = applyFieldFormat(formats, key, value)
 	if truncate != nil && truncate.FieldName == key {
 		return truncate.Truncate(value)
 	}
@@ -291,7 +453,7 @@ func fmtValue(truncate *Truncate, key, value string) string {
 }
 
 func fmtMessage(truncate *Truncate, message string) string {
-	return fmtValue(truncate, "message", message)
+	return fmtValue(truncate, nil, "message", message)
 }
 
 func shouldShowLogLine(args Args, config Config, logEntry *LogEntry) bool {
@@ -368,8 +530,3 @@ func shouldShowLogLineForWhereFilter(whereFields map[string]string, logEntry *Lo
 
 	return false
 }
-
-func sortFieldsAlphabetically(fields []string) []string {
-	sort.Strings(fields)
-	return fields
-}
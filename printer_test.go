@@ -3,6 +3,8 @@ package main
 import (
 	"strings"
 	"testing"
+
+	"github.com/fatih/color"
 )
 
 func TestPodPrefix(t *testing.T) {
@@ -29,3 +31,142 @@ func TestPodPrefix(t *testing.T) {
 		}
 	})
 }
+
+// formatterTest is an entry formatted end to end by formatSingleLine and
+// formatMultiLine, with colors off and the block message layout unless args
+// says otherwise.
+type formatterTest struct {
+	name   string
+	args   Args
+	config func(config *Config)
+	entry  *LogEntry
+	want   string
+}
+
+func runFormatterTests(t *testing.T, format func(Args, Config, *LogEntry, *PodColorizer, *TimeFormatter) string, tests []formatterTest) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := *newDefaultConfig()
+			if tt.config != nil {
+				tt.config(&config)
+			}
+			args := tt.args
+			if args.MessageLayout == "" {
+				args.MessageLayout = messageLayoutBlock
+			}
+
+			got := format(args, config, tt.entry, nil, nil)
+			if got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSingleLine(t *testing.T) {
+	runFormatterTests(t, formatSingleLine, []formatterTest{
+		{
+			name:  "message and fields on one line",
+			entry: &LogEntry{Level: "info", Time: "12:00", Message: "started", Fields: map[string]string{"b": "2", "a": "1"}, IsParsed: true},
+			want:  "[info] 12:00 - started - a=[1] b=[2]",
+		},
+		{
+			name:  "fields are ordered by their raw names",
+			entry: &LogEntry{Level: "info", Time: "12:00", Message: "m", Fields: map[string]string{"user.name": "ada", "User": "x", "a_b": "1", "a.c": "2"}, IsParsed: true},
+			want:  "[info] 12:00 - m - User=[x] a.c=[2] a_b=[1] user.name=[ada]",
+		},
+		{
+			name: "pinned fields come first, in the order listed",
+			config: func(config *Config) {
+				config.FieldOrder = &FieldOrderConfig{Pinned: []string{"trace.*", "user"}, Sort: fieldOrderAlphabetical}
+			},
+			entry: &LogEntry{Level: "info", Time: "12:00", Message: "m", Fields: map[string]string{"a": "1", "user": "ada", "trace.span": "s1", "trace.id": "t1"}, IsParsed: true},
+			want:  "[info] 12:00 - m - trace.id=[t1] trace.span=[s1] user=[ada] a=[1]",
+		},
+		{
+			name: "source order keeps the order of the JSON line",
+			config: func(config *Config) {
+				config.FieldOrder = &FieldOrderConfig{Pinned: []string{"user"}, Sort: fieldOrderSource}
+			},
+			entry: &LogEntry{Level: "info", Time: "12:00", Message: "m", Fields: map[string]string{"z": "1", "user": "ada", "b": "2"}, SourceOrder: []string{"level", "z", "b", "user"}, IsParsed: true},
+			want:  "[info] 12:00 - m - user=[ada] z=[1] b=[2]",
+		},
+		{
+			name:  "the error follows the message, before the fields",
+			entry: &LogEntry{Level: "error", Time: "12:00", Message: "failed", Error: "boom", ErrorKey: "error", Fields: map[string]string{"error": "boom", "a": "1"}, IsParsed: true},
+			want:  "[error] 12:00 - failed - error: boom - a=[1]",
+		},
+		{
+			name: "the stack trace is a block after the line",
+			entry: &LogEntry{
+				Level: "error", Time: "12:00", Message: "failed",
+				Error: "boom", ErrorKey: "error", Stack: "main.run()\n\tmain.go:12", StackKey: "stack",
+				Fields:   map[string]string{"error": "boom", "stack": "main.run()\n\tmain.go:12", "a": "1"},
+				IsParsed: true,
+			},
+			want: "[error] 12:00 - failed - error: boom - a=[1]\n    main.run()\n        main.go:12",
+		},
+		{
+			name:  "an error formatted with its stack trace shows its first line",
+			entry: &LogEntry{Level: "error", Time: "12:00", Message: "failed", Error: "boom\nmain.go:12", ErrorKey: "error", Fields: map[string]string{"error": "boom\nmain.go:12"}, IsParsed: true},
+			want:  "[error] 12:00 - failed - error: boom\n    main.go:12",
+		},
+		{
+			name:  "the error starts the line after a block message",
+			entry: &LogEntry{Level: "error", Time: "12:00", Message: "first\nsecond", Error: "boom", ErrorKey: "error", Fields: map[string]string{"error": "boom", "a": "1"}, IsParsed: true},
+			want:  "[error] 12:00 - first\n    second\n    error: boom - a=[1]",
+		},
+		{
+			name:  "a three-line message is a block with the fields on their own line",
+			entry: &LogEntry{Level: "info", Time: "12:00", Message: "first\nsecond\nthird", Fields: map[string]string{"a": "1", "b": "2"}, IsParsed: true},
+			want:  "[info] 12:00 - first\n    second\n    third\n    a=[1] b=[2]",
+		},
+		{
+			name:  "a three-line message without fields ends with the block",
+			entry: &LogEntry{Level: "info", Time: "12:00", Message: "first\nsecond\nthird", Fields: map[string]string{}, IsParsed: true},
+			want:  "[info] 12:00 - first\n    second\n    third",
+		},
+		{
+			name:  "the escape layout keeps a message on one line",
+			args:  Args{MessageLayout: messageLayoutEscape},
+			entry: &LogEntry{Level: "info", Time: "12:00", Message: "first\nsecond", Fields: map[string]string{"a": "1"}, IsParsed: true},
+			want:  "[info] 12:00 - first\\nsecond - a=[1]",
+		},
+	})
+}
+
+func TestFormatMultiLine(t *testing.T) {
+	runFormatterTests(t, formatMultiLine, []formatterTest{
+		{
+			name:  "one field per line after the message",
+			entry: &LogEntry{Level: "info", Time: "12:00", Message: "started", Fields: map[string]string{"b": "2", "a": "1"}, IsParsed: true},
+			want:  "[info] 12:00 - started\n  a: 1\n  b: 2",
+		},
+		{
+			name: "pinned fields come first, the rest in source order",
+			config: func(config *Config) {
+				config.FieldOrder = &FieldOrderConfig{Pinned: []string{"trace.id"}, Sort: fieldOrderSource}
+			},
+			entry: &LogEntry{Level: "info", Time: "12:00", Message: "m", Fields: map[string]string{"z": "1", "trace.id": "t1", "b": "2"}, SourceOrder: []string{"z", "b", "trace.id"}, IsParsed: true},
+			want:  "[info] 12:00 - m\n  trace.id: t1\n  z: 1\n  b: 2",
+		},
+		{
+			name: "the error and stack trace follow the message, before the fields",
+			entry: &LogEntry{
+				Level: "error", Time: "12:00", Message: "failed",
+				Error: "boom", ErrorKey: "error", Stack: "main.run()", StackKey: "stack",
+				Fields:   map[string]string{"error": "boom", "stack": "main.run()", "a": "1"},
+				IsParsed: true,
+			},
+			want: "[error] 12:00 - failed\n  error: boom\n    main.run()\n  a: 1",
+		},
+		{
+			name:  "a three-line message is a block before the fields",
+			entry: &LogEntry{Level: "info", Time: "12:00", Message: "first\nsecond\nthird", Fields: map[string]string{"a": "1"}, IsParsed: true},
+			want:  "[info] 12:00 - first\n    second\n    third\n  a: 1",
+		},
+	})
+}
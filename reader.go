@@ -69,7 +69,11 @@ func parseLogLine(line []byte, lineCount int, config Config) *LogEntry {
 		logEntry.setOriginalLogLine(rest)
 	} else {
 		logEntry.setFromJsonMap(parsedLogLine, *config.Keywords)
+		if config.FieldOrder.sortsBySource() {
+			logEntry.SourceOrder = jsonKeyOrder(rest)
+		}
 	}
+	logEntry.App = deriveAppName(config.AppNames, logEntry)
 
 	if isDebug() {
 		fmt.Printf("==== BEGIN DEBUG LINE %d ====\n", lineCount)
@@ -0,0 +1,54 @@
+package main
+
+import "sort"
+
+// textSpan styles the bytes [Start, End) of a text on top of the text's own
+// style.
+type textSpan struct {
+	Start int
+	End   int
+	Style *Style
+}
+
+// renderSpans renders text piece by piece: every piece gets base merged with
+// the styles of all spans covering it, in the order the spans are listed, so a
+// span listed after an enclosing one (e.g. a capture group inside its match)
+// wins. render styles one piece, e.g. styleString for terminal output.
+func renderSpans(text string, base *Style, spans []textSpan, render func(style *Style, text string) string) string {
+	if len(spans) == 0 {
+		return render(base, text)
+	}
+
+	boundaries := []int{0, len(text)}
+	for _, span := range spans {
+		boundaries = append(boundaries, span.Start, span.End)
+	}
+	sort.Ints(boundaries)
+
+	var out string
+	for i := 0; i+1 < len(boundaries); i++ {
+		start, end := boundaries[i], boundaries[i+1]
+		if start == end {
+			continue
+		}
+
+		style := base
+		for _, span := range spans {
+			if span.Start <= start && end <= span.End {
+				style = mergeStyles(style, span.Style)
+			}
+		}
+		out += render(style, text[start:end])
+	}
+	return out
+}
+
+// overlapsAny reports whether [start, end) overlaps any of the spans.
+func overlapsAny(spans []textSpan, start, end int) bool {
+	for _, span := range spans {
+		if start < span.End && span.Start < end {
+			return true
+		}
+	}
+	return false
+}
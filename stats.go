@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultStatsTop is how many of the most frequent messages and field names
+// the --stats report lists.
+const defaultStatsTop = 10
+
+// logStats summarizes the lines read for the --stats report. Everything but
+// Total and Filtered covers only the lines that pass the filters.
+type logStats struct {
+	Total    int
+	Filtered int
+	Parsed   int
+	Unparsed int
+
+	Levels     map[string]int
+	Pods       map[string]int
+	Apps       map[string]int
+	Messages   map[string]int
+	FieldNames map[string]int
+
+	// First and Last are the earliest and latest timestamps, and FirstTime and
+	// LastTime those lines' timestamps as logged.
+	First     time.Time
+	Last      time.Time
+	FirstTime string
+	LastTime  string
+	Timed     bool
+}
+
+func newLogStats() *logStats {
+	return &logStats{
+		Levels:     make(map[string]int),
+		Pods:       make(map[string]int),
+		Apps:       make(map[string]int),
+		Messages:   make(map[string]int),
+		FieldNames: make(map[string]int),
+	}
+}
+
+// add counts a line, which the filters either let through or not.
+func (s *logStats) add(logEntry *LogEntry, shown bool) {
+	s.Total++
+	if !shown {
+		s.Filtered++
+		return
+	}
+
+	if logEntry.IsParsed {
+		s.Parsed++
+	} else {
+		s.Unparsed++
+	}
+
+	if logEntry.Level != "" {
+		s.Levels[logEntry.Level]++
+	}
+	if logEntry.PodID != "" {
+		s.Pods[logEntry.PodID]++
+	}
+	if app := entryApp(logEntry); app != "" {
+		s.Apps[app]++
+	}
+	if logEntry.Message != "" {
+		// Messages spanning several lines are counted on one line of the report.
+		s.Messages[strings.ReplaceAll(logEntry.Message, "\n", `\n`)]++
+	}
+	for fieldName := range logEntry.Fields {
+		s.FieldNames[fieldName]++
+	}
+
+	if t, ok := parseEntryTime(logEntry); ok {
+		if !s.Timed || t.Before(s.First) {
+			s.First, s.FirstTime = t, logEntry.Time
+		}
+		if !s.Timed || t.After(s.Last) {
+			s.Last, s.LastTime = t, logEntry.Time
+		}
+		s.Timed = true
+	}
+}
+
+// collectStats counts every entry on its way to the printer, passing on the
+// ones to print. The stats are complete once the returned channel closes.
+func collectStats(ctx context.Context, args Args, config Config, logEntries <-chan *LogEntry, stats *logStats) <-chan *LogEntry {
+	passed := make(chan *LogEntry, 1)
+
+	go func() {
+		defer close(passed)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case logEntry, ok := <-logEntries:
+				if !ok {
+					return
+				}
+				stats.add(logEntry, shouldShowLogLine(args, config, logEntry))
+				sendToPrinter(ctx, passed, logEntry)
+			}
+		}
+	}()
+
+	return passed
+}
+
+// printStatsOnly reads the whole stream for --stats-only and prints the report
+// in place of the lines.
+func printStatsOnly(ctx context.Context, args Args, config Config, logEntries <-chan *LogEntry) {
+	stats := newLogStats()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case logEntry, ok := <-logEntries:
+			if !ok {
+				fmt.Print(formatStats(config, stats, args.StatsTop))
+				return
+			}
+			stats.add(logEntry, shouldShowLogLine(args, config, logEntry))
+		}
+	}
+}
+
+// statsOutput is where the --stats report goes after the lines: stdout, or
+// stderr with --output html|csv|tsv so the file written stays valid.
+func statsOutput(args Args) io.Writer {
+	if args.Output != outputText {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// namedCount is how many lines have a pod, app, message or field name.
+type namedCount struct {
+	Name  string
+	Count int
+}
+
+// topCounts orders counts most frequent first, by name when tied, keeping the
+// first top of them. A top of 0 keeps every count.
+func topCounts(counts map[string]int, top int) []namedCount {
+	sorted := make([]namedCount, 0, len(counts))
+	for name, count := range counts {
+		sorted = append(sorted, namedCount{Name: name, Count: count})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	if top > 0 && len(sorted) > top {
+		sorted = sorted[:top]
+	}
+	return sorted
+}
+
+// formatStats lays out the --stats report: the line counts and time span
+// covered, then the lines per level, pod and app, and the top most frequent
+// messages and field names, each count right-aligned under a section title.
+func formatStats(config Config, stats *logStats, top int) string {
+	var b strings.Builder
+
+	fmt.Fprintln(&b, groupHeaderStyle().Sprintf("%s stats %s", groupHeaderRule, groupHeaderRule))
+
+	lines := []string{fmt.Sprintf("%d total", stats.Total)}
+	if stats.Filtered > 0 {
+		lines = append(lines, fmt.Sprintf("%d filtered out", stats.Filtered))
+	}
+	lines = append(lines, fmt.Sprintf("%d parsed", stats.Parsed), fmt.Sprintf("%d unparsed", stats.Unparsed))
+	fmt.Fprintf(&b, "Lines      %s\n", strings.Join(lines, " · "))
+
+	if stats.Timed {
+		span := stats.Last.Sub(stats.First)
+		timeSpan := []string{stats.FirstTime + " → " + stats.LastTime, formatDuration(span)}
+		if span > 0 {
+			timeSpan = append(timeSpan, fmt.Sprintf("%.1f lines/s", float64(stats.Parsed+stats.Unparsed)/span.Seconds()))
+		}
+		fmt.Fprintf(&b, "Time span  %s\n", strings.Join(timeSpan, " · "))
+	}
+
+	var levels []levelCount
+	for level, count := range stats.Levels {
+		levels = append(levels, levelCount{Level: level, Count: count})
+	}
+	sortLevelCounts(levels, config.LogLevelToSeverity)
+	levelCounts := make([]namedCount, len(levels))
+	for i, level := range levels {
+		levelCounts[i] = namedCount{Name: level.Level, Count: level.Count}
+	}
+
+	writeStatsSection(&b, "Levels", levelCounts, func(name string) string {
+		return styleString(resolveLevelStyle(name, config.LevelStyles), name)
+	})
+	writeStatsSection(&b, "Pods", topCounts(stats.Pods, 0), nil)
+	writeStatsSection(&b, "Apps", topCounts(stats.Apps, 0), nil)
+	if top > 0 {
+		writeStatsSection(&b, "Top messages", topCounts(stats.Messages, top), nil)
+		writeStatsSection(&b, "Top fields", topCounts(stats.FieldNames, top), nil)
+	}
+
+	return b.String()
+}
+
+// writeStatsSection writes a titled list of counts, leaving out empty ones.
+// style, when given, styles each name.
+func writeStatsSection(b *strings.Builder, title string, counts []namedCount, style func(name string) string) {
+	if len(counts) == 0 {
+		return
+	}
+
+	width := len(fmt.Sprint(counts[0].Count))
+	for _, count := range counts[1:] {
+		if w := len(fmt.Sprint(count.Count)); w > width {
+			width = w
+		}
+	}
+
+	fmt.Fprintf(b, "\n%s\n", groupHeaderStyle().Sprint(title))
+	for _, count := range counts {
+		name := count.Name
+		if style != nil {
+			name = style(name)
+		}
+		fmt.Fprintf(b, "  %*d  %s\n", width, count.Count, name)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatStats(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	config := *newDefaultConfig()
+	stats := newLogStats()
+	entries := []*LogEntry{
+		{PodID: "booking-api-7d8f9b6c5-x2k4p", Level: "info", Message: "request finished", Time: "2026-06-25T12:00:00Z", Fields: map[string]string{"http.route": "/a"}, IsParsed: true},
+		{PodID: "booking-api-7d8f9b6c5-abcde", Level: "error", Message: "boom", Time: "2026-06-25T12:00:04Z", Fields: map[string]string{}, IsParsed: true},
+		{PodID: "gateway-5c4b3a2d1-qq9zz", Fields: map[string]string{}},
+		{Level: "info", Message: "request finished", Time: "2026-06-25T12:00:02Z", Fields: map[string]string{"http.route": "/b"}, IsParsed: true},
+	}
+	for _, entry := range entries {
+		stats.add(entry, true)
+	}
+	stats.add(&LogEntry{Level: "debug", Message: "noise", IsParsed: true}, false)
+
+	got := formatStats(config, stats, 1)
+	want := `══ stats ══
+Lines      5 total · 1 filtered out · 3 parsed · 1 unparsed
+Time span  2026-06-25T12:00:00Z → 2026-06-25T12:00:04Z · 4s · 1.0 lines/s
+
+Levels
+  1  error
+  2  info
+
+Pods
+  1  booking-api-7d8f9b6c5-abcde
+  1  booking-api-7d8f9b6c5-x2k4p
+  1  gateway-5c4b3a2d1-qq9zz
+
+Apps
+  2  booking-api
+  1  gateway
+
+Top messages
+  2  request finished
+
+Top fields
+  2  http.route
+`
+
+	if got != want {
+		t.Errorf("formatStats() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatStatsWithoutTop(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	stats := newLogStats()
+	stats.add(&LogEntry{Message: "no timestamp", Fields: map[string]string{"user": "ada"}, IsParsed: true}, true)
+
+	got := formatStats(*newDefaultConfig(), stats, 0)
+	want := "══ stats ══\nLines      1 total · 1 parsed · 0 unparsed\n"
+
+	if got != want {
+		t.Errorf("formatStats() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestTopCounts(t *testing.T) {
+	got := topCounts(map[string]int{"b": 2, "a": 2, "c": 5, "d": 1}, 3)
+	want := []namedCount{{"c", 5}, {"a", 2}, {"b", 2}}
+
+	if len(got) != len(want) {
+		t.Fatalf("topCounts() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("topCounts()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -74,6 +74,29 @@ var DefaultTimestampStyles = map[string]Style{
 	DefaultStylesKey: {
 		FgColor: getColorCode(color.FgBlue),
 	},
+	HighlightStylesKey: {
+		FgColor: getColorCode(color.FgHiYellow),
+		Bold:    boolPtr(true),
+	},
+}
+
+// ErrorStyles keys for the parts of a stack trace. The default style is used
+// for the error itself.
+const ErrorStackStylesKey = "stack"
+const ErrorFrameStylesKey = "frame"
+
+var DefaultErrorStyles = map[string]Style{
+	DefaultStylesKey: {
+		FgColor: getColorCode(color.FgHiRed),
+		Bold:    boolPtr(true),
+	},
+	ErrorStackStylesKey: {
+		FgColor: getColorCode(color.FgHiBlack),
+	},
+	ErrorFrameStylesKey: {
+		FgColor:   getColorCode(color.FgCyan),
+		Underline: boolPtr(true),
+	},
 }
 
 var DefaultExcludedWarningTextStyles = map[string]Style{
@@ -135,183 +158,208 @@ var colorCodes = map[string]color.Attribute{
 }
 
 func applyStyles(styles *Style) *color.Color {
-	c := color.New()
+	return newColor(styleAttributes(styles)...)
+}
+
+// styleAttributes returns the SGR parameters that make up a style.
+func styleAttributes(styles *Style) []color.Attribute {
+	var attributes []color.Attribute
 	if styles.BgColor != nil {
-		c.Add(colorCodes[*styles.BgColor])
+		attributes = append(attributes, styleColorAttributes(*styles.BgColor, true)...)
 	}
 	if styles.FgColor != nil {
-		c.Add(colorCodes[*styles.FgColor])
+		attributes = append(attributes, styleColorAttributes(*styles.FgColor, false)...)
 	}
 	if styles.Bold != nil && *styles.Bold {
-		c.Add(color.Bold)
+		attributes = append(attributes, color.Bold)
 	}
 	if styles.Underline != nil && *styles.Underline {
-		c.Add(color.Underline)
+		attributes = append(attributes, color.Underline)
 	}
 	if styles.Italic != nil && *styles.Italic {
-		c.Add(color.Italic)
+		attributes = append(attributes, color.Italic)
+	}
+	return attributes
+}
+
+// styleString renders text with the given style, or unstyled when style is nil.
+func styleString(style *Style, text string) string {
+	if style == nil {
+		return newColor().Sprint(text)
 	}
-	return c
+	return applyStyles(style).Sprint(text)
 }
 
 func applyTimestampStyle(timestamp string, styles map[string]Style) string {
-	defaultTimestamp := color.New().Sprint(timestamp)
+	return styleString(resolveTimestampStyle(timestamp, styles), timestamp)
+}
 
+// resolveTimestampStyle returns the style a timestamp is rendered with: an
+// override matching the timestamp, else the default style, else nil.
+func resolveTimestampStyle(timestamp string, styles map[string]Style) *Style {
 	if styles == nil {
 		logDebug("No timestamp styles defined in config, falling back on defaults\n")
 		styles = DefaultTimestampStyles
 	}
 
-	defaultStyle, ok := styles[DefaultStylesKey]
-	if ok {
-		logDebug("Applying default styles %+v for timestamp\n", defaultStyle)
-		defaultTimestamp = applyStyles(&defaultStyle).Sprint(timestamp)
+	style := findStyleOverride(styles, timestamp)
+	if style != nil {
+		logDebug("Applying styles %+v for timestamp\n", style)
+		return style
 	}
 
-	style := findStyleOverride(styles, timestamp)
-	if style == nil {
-		logDebug("No style defined for timestamp %s\n", timestamp)
-		return defaultTimestamp
+	logDebug("No style defined for timestamp %s\n", timestamp)
+	if defaultStyle, ok := styles[DefaultStylesKey]; ok {
+		return &defaultStyle
+	}
+	return nil
+}
+
+// resolveTimestampHighlightStyle returns the style for a timestamp that stands
+// out, such as a --time-format delta above the TimeDeltaThreshold.
+func resolveTimestampHighlightStyle(styles map[string]Style) *Style {
+	if styles == nil {
+		styles = DefaultTimestampStyles
+	}
+
+	if style, ok := styles[HighlightStylesKey]; ok {
+		return &style
 	}
+	if style, ok := DefaultTimestampStyles[HighlightStylesKey]; ok {
+		return &style
+	}
+	return nil
+}
 
-	logDebug("Applying styles %+v for timestamp\n", style)
-	return applyStyles(style).Sprint(timestamp)
+// resolveErrorStyle returns the ErrorStyles style for key: the error itself
+// (DefaultStylesKey), a stack trace line or a file:line frame in one. Keys
+// missing from the config fall back to the default error styles.
+func resolveErrorStyle(key string, styles map[string]Style) *Style {
+	if style, ok := styles[key]; ok {
+		return &style
+	}
+	if style, ok := DefaultErrorStyles[key]; ok {
+		return &style
+	}
+	return nil
 }
 
 func applyExcludedFieldsWarningTextStyle(text string, styles map[string]Style) string {
-	defaultText := color.New().Sprint(text)
+	return styleString(resolveExcludedFieldsWarningTextStyle(text, styles), text)
+}
 
+func resolveExcludedFieldsWarningTextStyle(text string, styles map[string]Style) *Style {
 	if styles == nil {
 		styles = DefaultExcludedWarningTextStyles
 	}
 
-	defaultStyle, ok := styles[DefaultStylesKey]
-	if ok {
-		defaultText = applyStyles(&defaultStyle).Sprint(text)
+	if style := findStyleOverride(styles, text); style != nil {
+		return style
 	}
 
-	style := findStyleOverride(styles, defaultText)
-	if style == nil {
-		return defaultText
+	if defaultStyle, ok := styles[DefaultStylesKey]; ok {
+		return &defaultStyle
 	}
-
-	return applyStyles(style).Sprint(text)
+	return nil
 }
 
 func applyMessageStyle(message string, styles map[string]Style) string {
-	defaultMessage := color.New().Sprint(message)
+	return styleString(resolveMessageStyle(message, styles), message)
+}
 
+// resolveMessageStyle returns the style a message is rendered with: an override
+// matching the message, else the default style, else nil.
+func resolveMessageStyle(message string, styles map[string]Style) *Style {
 	if styles == nil {
 		logDebug("No message styles defined in config, falling back on defaults\n")
 		styles = DefaultMessageStyles
 	}
 
-	defaultStyle, ok := styles[DefaultStylesKey]
-	if ok {
-		logDebug("Applying default styles %+v for message\n", defaultStyle)
-		defaultMessage = applyStyles(&defaultStyle).Sprint(message)
-	}
-
 	style := findStyleOverride(styles, message)
-	if style == nil {
-		logDebug("No style defined for message %s\n", message)
-		return defaultMessage
+	if style != nil {
+		logDebug("Applying styles %+v for message\n", style)
+		return style
 	}
 
-	logDebug("Applying styles %+v for message\n", style)
-	return applyStyles(style).Sprint(message)
+	logDebug("No style defined for message %s\n", message)
+	if defaultStyle, ok := styles[DefaultStylesKey]; ok {
+		return &defaultStyle
+	}
+	return nil
 }
 
 func applyLevelStyle(level string, styles map[string]Style) string {
-	defaultLevel := color.New().Sprint(level)
+	return styleString(resolveLevelStyle(level, styles), level)
+}
 
+// resolveLevelStyle returns the style a log level is rendered with: the style
+// for that exact level, else the default style, else nil.
+func resolveLevelStyle(level string, styles map[string]Style) *Style {
 	if styles == nil {
 		logDebug("No level styles defined in config, falling back on defaults\n")
 		styles = DefaultLevelStyles
 	}
 
-	style, ok := styles[DefaultStylesKey]
-	if ok {
-		logDebug("Applying default styles %+v for level %s\n", style, level)
-		defaultLevel = applyStyles(&style).Sprint(level)
+	if style, ok := styles[level]; ok {
+		logDebug("Applying styles %+v for level %s\n", style, level)
+		return &style
 	}
 
-	style, ok = styles[level]
-	if !ok {
-		logDebug("No style defined for level %s\n", level)
-		return defaultLevel
+	logDebug("No style defined for level %s\n", level)
+	if style, ok := styles[DefaultStylesKey]; ok {
+		return &style
 	}
-
-	logDebug("Applying styles %+v for level %s\n", style, level)
-	return applyStyles(&style).Sprint(level)
+	return nil
 }
 
-func applyFieldNameStyle(fieldName string, styles map[string]KeyValueStyle, highlightKey string) string {
-	defaultFieldName := color.New().Sprint(fieldName)
+func applyFieldNameStyle(fieldName string, styles map[string]KeyValueStyle, highlightKeys []Highlight) string {
+	return styleString(resolveFieldNameStyle(fieldName, styles, highlightKeys), fieldName)
+}
 
+// resolveFieldNameStyle returns the style a field name is rendered with: the
+// style of the first --highlight-key it matches, else the field's own key
+// style, else the default key style, else nil.
+func resolveFieldNameStyle(fieldName string, styles map[string]KeyValueStyle, highlightKeys []Highlight) *Style {
 	if styles == nil {
 		logDebug("No field styles defined in config, falling back on defaults\n")
 		styles = DefaultFieldStyles
 	}
 
-	defaultStyles, ok := styles[DefaultStylesKey]
-	if ok && defaultStyles.Key != nil {
-		defaultFieldName = applyStyles(defaultStyles.Key).Sprint(fieldName)
+	if style := findHighlight(highlightKeys, fieldName); style != nil {
+		return style
 	}
 
-	highlightedFieldName, highlighted := tryApplyHighlightStyle(fieldName, highlightKey, styles, func(s KeyValueStyle) *Style { return s.Key })
-	if highlighted {
-		return highlightedFieldName
+	if style := findKeyValueStyle(styles, fieldName); style != nil && style.Key != nil {
+		logDebug("Applying styles %+v for field %s\n", style.Key, fieldName)
+		return style.Key
 	}
 
-	style := findKeyValueStyle(styles, fieldName)
-	if style == nil {
-		logDebug("No style defined for field %s\n", fieldName)
-		return defaultFieldName
+	logDebug("No key style defined for field %s\n", fieldName)
+	if defaultStyles, ok := styles[DefaultStylesKey]; ok {
+		return defaultStyles.Key
 	}
-
-	fieldNameStyle := style.Key
-	if fieldNameStyle == nil {
-		logDebug("No key style defined for field %s\n", fieldName)
-		return defaultFieldName
-	}
-
-	logDebug("Applying styles %+v for field %s\n", fieldNameStyle, fieldName)
-	return applyStyles(fieldNameStyle).Sprint(fieldName)
+	return nil
 }
 
-func applyFieldValueStyle(fieldName, fieldValue string, styles map[string]KeyValueStyle, highlightValue string) string {
-	defaultFieldValue := color.New().Sprint(fieldValue)
-
+// resolveFieldValueStyle returns the style a field value is rendered with: the
+// field's own value style, else the default value style, else nil. Highlights
+// are drawn over it as spans, see highlightSpans.
+func resolveFieldValueStyle(fieldName, fieldValue string, styles map[string]KeyValueStyle) *Style {
 	if styles == nil {
 		logDebug("No field styles defined in config, falling back on defaults\n")
 		styles = DefaultFieldStyles
 	}
 
-	defaultStyles, ok := styles[DefaultStylesKey]
-	if ok && defaultStyles.Value != nil {
-		defaultFieldValue = applyStyles(defaultStyles.Value).Sprint(fieldValue)
-	}
-
-	highlightedFieldValue, highlighted := tryApplyHighlightStyle(fieldValue, highlightValue, styles, func(s KeyValueStyle) *Style { return s.Value })
-	if highlighted {
-		return highlightedFieldValue
-	}
-
-	style := findKeyValueStyle(styles, fieldName)
-	if style == nil {
-		logDebug("No style defined for field %s\n", fieldName)
-		return defaultFieldValue
+	if style := findKeyValueStyle(styles, fieldName); style != nil && style.Value != nil {
+		logDebug("Applying styles %+v for field %s\n", style.Value, fieldValue)
+		return style.Value
 	}
 
-	fieldValueStyle := style.Value
-	if fieldValueStyle == nil {
-		logDebug("No value style defined for field %s\n", fieldName)
-		return defaultFieldValue
+	logDebug("No value style defined for field %s\n", fieldName)
+	if defaultStyles, ok := styles[DefaultStylesKey]; ok {
+		return defaultStyles.Value
 	}
-
-	logDebug("Applying styles %+v for field %s\n", fieldValueStyle, fieldValue)
-	return applyStyles(fieldValueStyle).Sprint(fieldValue)
+	return nil
 }
 
 func findKeyValueStyle(styles map[string]KeyValueStyle, fieldName string) *KeyValueStyle {
@@ -408,30 +456,41 @@ func findStyleOverride(styles map[string]Style, fieldName string) *Style {
 	return nil
 }
 
-func tryApplyHighlightStyle(value string, highlight string, styles map[string]KeyValueStyle, selectStyle func(s KeyValueStyle) *Style) (string, bool) {
-	if highlight == "" {
-		return value, false
-	}
+// defaultHighlightStyle is used for --highlight-key/--highlight-value matches
+// when the config has no "highlight" field style.
+var defaultHighlightStyle = Style{
+	FgColor:   getColorCode(color.FgHiRed),
+	Bold:      boolPtr(true),
+	Italic:    boolPtr(true),
+	Underline: boolPtr(true),
+}
 
-	defaultHlStyle := color.New(color.FgHiRed, color.Bold, color.Italic, color.Underline)
+// highlightStyle returns the configured highlight style for the key or value
+// side (chosen by selectStyle), falling back to defaultHighlightStyle.
+func highlightStyle(styles map[string]KeyValueStyle, selectStyle func(s KeyValueStyle) *Style) *Style {
+	if hlStyle, ok := styles[HighlightStylesKey]; ok && selectStyle(hlStyle) != nil {
+		return selectStyle(hlStyle)
+	}
+	style := defaultHighlightStyle
+	return &style
+}
 
-	applyHighlightStyle := func(value string) string {
-		hlStyle, hasHlStyle := styles["highlight"]
-		if hasHlStyle && selectStyle(hlStyle) != nil {
-			return applyStyles(selectStyle(hlStyle)).Sprint(value)
-		}
-		return defaultHlStyle.Sprint(value)
+// matchesHighlight reports whether value matches the highlight pattern, which
+// may have a leading and/or trailing wildcard.
+func matchesHighlight(value string, highlight string) bool {
+	if highlight == "" {
+		return false
 	}
 
 	if highlight == value {
-		return applyHighlightStyle(value), true
+		return true
 	}
 
 	if strings.HasPrefix(highlight, "*") {
 		cleanKey := strings.TrimPrefix(highlight, "*")
 
 		if strings.HasSuffix(value, cleanKey) {
-			return applyHighlightStyle(value), true
+			return true
 		}
 	}
 
@@ -439,7 +498,7 @@ func tryApplyHighlightStyle(value string, highlight string, styles map[string]Ke
 		cleanKey := strings.TrimSuffix(highlight, "*")
 
 		if strings.HasPrefix(value, cleanKey) {
-			return applyHighlightStyle(value), true
+			return true
 		}
 	}
 
@@ -447,9 +506,9 @@ func tryApplyHighlightStyle(value string, highlight string, styles map[string]Ke
 		cleanKey := strings.Trim(highlight, "*")
 
 		if strings.Contains(value, cleanKey) {
-			return applyHighlightStyle(value), true
+			return true
 		}
 	}
 
-	return value, false
+	return false
 }
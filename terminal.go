@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/mattn/go-isatty"
+)
+
+// stdoutIsTerminal reports whether stdout is attached to a terminal rather than
+// a pipe or file.
+func stdoutIsTerminal() bool {
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// detectTerminalWidth returns the column width of the terminal stdout is
+// attached to, falling back to $COLUMNS, or 0 when stdout isn't a terminal.
+func detectTerminalWidth() int {
+	if !stdoutIsTerminal() {
+		return 0
+	}
+
+	if width := terminalWidth(os.Stdout.Fd()); width > 0 {
+		return width
+	}
+
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	return 0
+}
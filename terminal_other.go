@@ -0,0 +1,9 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris)
+
+package main
+
+// terminalWidth is not implemented on this platform; callers fall back to
+// $COLUMNS.
+func terminalWidth(fd uintptr) int {
+	return 0
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const ThemeEnvVar = "PRETTY_LOGRUS_THEME"
+
+// Theme is a named bundle of styles and pod colors. Styles are layered per key:
+// the default styles first, then the theme, then the styles set in the config
+// file, so a theme only needs the keys it changes.
+type Theme struct {
+	LevelStyles     map[string]Style
+	FieldStyles     map[string]KeyValueStyle
+	MessageStyles   map[string]Style
+	TimestampStyles map[string]Style
+	ErrorStyles     map[string]Style
+	// PodPalette is the list of colors cycled through for pod IDs.
+	PodPalette []string
+}
+
+func fgStyle(c string) Style {
+	return Style{FgColor: strPtr(c)}
+}
+
+func boldFgStyle(c string) Style {
+	return Style{FgColor: strPtr(c), Bold: boolPtr(true)}
+}
+
+func fieldStyle(key, value Style) KeyValueStyle {
+	return KeyValueStyle{Key: &key, Value: &value}
+}
+
+var builtinThemes = map[string]Theme{
+	// dark is the default styles, made for dark terminal backgrounds.
+	"dark": {},
+
+	"light": {
+		LevelStyles: map[string]Style{
+			DefaultStylesKey: fgStyle("fgGreen"),
+			"trace":          fgStyle("fgHiBlack"),
+			"debug":          fgStyle("fgCyan"),
+			"info":           fgStyle("fgGreen"),
+			"warning":        fgStyle("#af5f00"),
+			"error":          fgStyle("fgRed"),
+			"err":            fgStyle("fgRed"),
+			"fatal":          boldFgStyle("fgRed"),
+			"panic":          boldFgStyle("fgRed"),
+		},
+		FieldStyles: map[string]KeyValueStyle{
+			DefaultStylesKey: fieldStyle(fgStyle("fgBlue"), fgStyle("fgGreen")),
+		},
+		MessageStyles: map[string]Style{
+			DefaultStylesKey: fgStyle("fgBlack"),
+		},
+		TimestampStyles: map[string]Style{
+			DefaultStylesKey:   fgStyle("fgHiBlack"),
+			HighlightStylesKey: boldFgStyle("fgMagenta"),
+		},
+		PodPalette: []string{"fgBlue", "fgMagenta", "fgCyan", "#af5f00", "fgGreen", "fgRed", "#5f00af", "#005f87"},
+	},
+
+	"solarized": {
+		LevelStyles: map[string]Style{
+			DefaultStylesKey: fgStyle("#859900"),
+			"trace":          fgStyle("#586e75"),
+			"debug":          fgStyle("#2aa198"),
+			"info":           fgStyle("#859900"),
+			"warning":        fgStyle("#b58900"),
+			"error":          fgStyle("#dc322f"),
+			"err":            fgStyle("#dc322f"),
+			"fatal":          boldFgStyle("#dc322f"),
+			"panic":          boldFgStyle("#dc322f"),
+		},
+		FieldStyles: map[string]KeyValueStyle{
+			DefaultStylesKey: fieldStyle(fgStyle("#b58900"), fgStyle("#2aa198")),
+		},
+		MessageStyles: map[string]Style{
+			DefaultStylesKey: fgStyle("#93a1a1"),
+		},
+		TimestampStyles: map[string]Style{
+			DefaultStylesKey:   fgStyle("#268bd2"),
+			HighlightStylesKey: boldFgStyle("#cb4b16"),
+		},
+		PodPalette: []string{"#2aa198", "#d33682", "#b58900", "#268bd2", "#859900", "#cb4b16", "#6c71c4", "#dc322f"},
+	},
+
+	"high-contrast": {
+		LevelStyles: map[string]Style{
+			DefaultStylesKey: boldFgStyle("fgHiGreen"),
+			"trace":          boldFgStyle("fgHiWhite"),
+			"debug":          boldFgStyle("fgHiCyan"),
+			"info":           boldFgStyle("fgHiGreen"),
+			"warning":        {FgColor: strPtr("fgBlack"), BgColor: strPtr("bgHiYellow"), Bold: boolPtr(true)},
+			"error":          {FgColor: strPtr("fgHiWhite"), BgColor: strPtr("bgRed"), Bold: boolPtr(true)},
+			"err":            {FgColor: strPtr("fgHiWhite"), BgColor: strPtr("bgRed"), Bold: boolPtr(true)},
+			"fatal":          {FgColor: strPtr("fgHiWhite"), BgColor: strPtr("bgRed"), Bold: boolPtr(true)},
+			"panic":          {FgColor: strPtr("fgHiWhite"), BgColor: strPtr("bgRed"), Bold: boolPtr(true)},
+		},
+		FieldStyles: map[string]KeyValueStyle{
+			DefaultStylesKey: fieldStyle(boldFgStyle("fgHiYellow"), fgStyle("fgHiWhite")),
+		},
+		MessageStyles: map[string]Style{
+			DefaultStylesKey: boldFgStyle("fgHiWhite"),
+		},
+		TimestampStyles: map[string]Style{
+			DefaultStylesKey:   fgStyle("fgHiCyan"),
+			HighlightStylesKey: {FgColor: strPtr("fgBlack"), BgColor: strPtr("bgHiYellow"), Bold: boolPtr(true)},
+		},
+		PodPalette: []string{"fgHiCyan", "fgHiMagenta", "fgHiYellow", "fgHiGreen", "fgHiBlue", "fgHiRed", "fgHiWhite"},
+	},
+}
+
+// findTheme looks up a theme by name, preferring the config file's Themes over
+// the built-in ones so a built-in theme can be redefined.
+func findTheme(config Config, name string) (Theme, error) {
+	if theme, ok := config.Themes[name]; ok {
+		return theme, nil
+	}
+	if theme, ok := builtinThemes[name]; ok {
+		return theme, nil
+	}
+
+	names := sortedKeys(builtinThemes)
+	for _, name := range sortedKeys(config.Themes) {
+		if _, builtin := builtinThemes[name]; !builtin {
+			names = append(names, name)
+		}
+	}
+	return Theme{}, fmt.Errorf("unknown theme %q, must be one of %s", name, strings.Join(names, "|"))
+}
+
+// applyTheme layers the named theme between the default styles and the styles
+// set in the config file. An empty name leaves the config as it is.
+func applyTheme(config *Config, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	theme, err := findTheme(*config, name)
+	if err != nil {
+		return err
+	}
+
+	defaults := newDefaultConfig()
+	overrides := config.fileStyles
+
+	config.LevelStyles = layerStyles(defaults.LevelStyles, theme.LevelStyles, changedStyles(overrides.LevelStyles, defaults.LevelStyles))
+	config.FieldStyles = layerStyles(defaults.FieldStyles, theme.FieldStyles, changedStyles(overrides.FieldStyles, defaults.FieldStyles))
+	config.MessageStyles = layerStyles(defaults.MessageStyles, theme.MessageStyles, changedStyles(overrides.MessageStyles, defaults.MessageStyles))
+	config.TimestampStyles = layerStyles(defaults.TimestampStyles, theme.TimestampStyles, changedStyles(overrides.TimestampStyles, defaults.TimestampStyles))
+	config.ErrorStyles = layerStyles(defaults.ErrorStyles, theme.ErrorStyles, changedStyles(overrides.ErrorStyles, defaults.ErrorStyles))
+
+	if len(config.PodPalette) == 0 {
+		config.PodPalette = theme.PodPalette
+	}
+
+	logDebug("Applied theme %s\n", name)
+	return nil
+}
+
+// layerStyles merges style maps key by key, later layers winning.
+func layerStyles[V any](layers ...map[string]V) map[string]V {
+	merged := make(map[string]V)
+	for _, layer := range layers {
+		for key, style := range layer {
+			merged[key] = style
+		}
+	}
+	return merged
+}
+
+// changedStyles returns the styles from the config file that differ from the
+// defaults. Config files created by `plr init` contain every default style, and
+// those must not mask the theme.
+func changedStyles[V any](fileStyles, defaults map[string]V) map[string]V {
+	changed := make(map[string]V)
+	for key, style := range fileStyles {
+		if defaultStyle, ok := defaults[key]; ok && reflect.DeepEqual(style, defaultStyle) {
+			continue
+		}
+		changed[key] = style
+	}
+	return changed
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinThemesUseValidColors(t *testing.T) {
+	config := Config{Themes: builtinThemes}
+	if problems := validateConfigColors(config); len(problems) != 0 {
+		t.Errorf("built-in themes have invalid colors: %v", problems)
+	}
+}
+
+func TestApplyTheme(t *testing.T) {
+	t.Run("layers the theme over the defaults", func(t *testing.T) {
+		config := newDefaultConfig()
+		if err := applyTheme(config, "light"); err != nil {
+			t.Fatalf("applyTheme() error = %v", err)
+		}
+
+		if got := *config.MessageStyles[DefaultStylesKey].FgColor; got != "fgBlack" {
+			t.Errorf("message color = %s, want the light theme's fgBlack", got)
+		}
+		if _, ok := config.FieldStyles[HighlightStylesKey]; !ok {
+			t.Errorf("default highlight field style missing after applying a theme without one")
+		}
+		if len(config.PodPalette) == 0 || config.PodPalette[0] != "fgBlue" {
+			t.Errorf("pod palette = %v, want the light theme's palette", config.PodPalette)
+		}
+	})
+
+	t.Run("keeps styles changed in the config file on top", func(t *testing.T) {
+		config := newDefaultConfig()
+		config.fileStyles = Theme{
+			LevelStyles: map[string]Style{
+				"info":  fgStyle("#00ff00"),
+				"error": DefaultLevelStyles["error"],
+			},
+		}
+
+		if err := applyTheme(config, "solarized"); err != nil {
+			t.Fatalf("applyTheme() error = %v", err)
+		}
+
+		if got := *config.LevelStyles["info"].FgColor; got != "#00ff00" {
+			t.Errorf("info color = %s, want the config file's #00ff00", got)
+		}
+		if got := *config.LevelStyles["error"].FgColor; got != "#dc322f" {
+			t.Errorf("error color = %s, want the theme's #dc322f since the config file only repeats the default", got)
+		}
+	})
+
+	t.Run("prefers themes from the config file", func(t *testing.T) {
+		config := newDefaultConfig()
+		config.Themes = map[string]Theme{"light": {MessageStyles: map[string]Style{DefaultStylesKey: fgStyle("fgBlue")}}}
+
+		if err := applyTheme(config, "light"); err != nil {
+			t.Fatalf("applyTheme() error = %v", err)
+		}
+		if got := *config.MessageStyles[DefaultStylesKey].FgColor; got != "fgBlue" {
+			t.Errorf("message color = %s, want the config file theme's fgBlue", got)
+		}
+	})
+
+	t.Run("rejects an unknown theme", func(t *testing.T) {
+		err := applyTheme(newDefaultConfig(), "neon")
+		if err == nil || !strings.Contains(err.Error(), "high-contrast") {
+			t.Errorf("applyTheme() error = %v, want an error listing the themes", err)
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// --time-format modes. Anything that isn't one of these is taken to be a Go
+// time layout, optionally preceded by "local" or "utc" (e.g. "local 15:04:05").
+const (
+	timeFormatRaw      = "raw"
+	timeFormatLocal    = "local"
+	timeFormatUTC      = "utc"
+	timeFormatRelative = "relative"
+	timeFormatDelta    = "delta"
+	timeFormatElapsed  = "elapsed"
+)
+
+// layoutProbeTime is used to check that a custom layout contains at least one
+// time element, so a mistyped mode isn't silently printed as literal text. It
+// must differ from the layout reference time in every element.
+var layoutProbeTime = time.Date(2001, time.November, 22, 23, 11, 12, 0, time.UTC)
+
+// TimeFormat describes how entry timestamps are displayed.
+type TimeFormat struct {
+	// Mode is raw (as logged), an absolute layout ("" with Layout set), or one
+	// of relative, delta and elapsed.
+	Mode string
+	// Layout is the Go time layout for absolute timestamps.
+	Layout string
+	// Location converts absolute timestamps to a time zone. Nil keeps the zone
+	// the timestamp was logged in.
+	Location *time.Location
+	// DeltaThreshold is the gap between two shown entries above which a delta
+	// timestamp is highlighted. Zero disables the highlight.
+	DeltaThreshold time.Duration
+}
+
+// parseTimeFormat parses a --time-format value (or the config's TimeFormat)
+// and the config's TimeDeltaThreshold.
+func parseTimeFormat(spec string, deltaThreshold string) (*TimeFormat, error) {
+	format := &TimeFormat{}
+
+	if deltaThreshold != "" {
+		threshold, err := time.ParseDuration(deltaThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time delta threshold %q: %v", deltaThreshold, err)
+		}
+		format.DeltaThreshold = threshold
+	}
+
+	spec = strings.TrimSpace(spec)
+
+	switch strings.ToLower(spec) {
+	case "", timeFormatRaw:
+		format.Mode = timeFormatRaw
+		return format, nil
+	case timeFormatRelative, timeFormatDelta, timeFormatElapsed:
+		format.Mode = strings.ToLower(spec)
+		return format, nil
+	}
+
+	format.Layout = time.RFC3339Nano
+
+	zone, layout, _ := strings.Cut(spec, " ")
+	switch strings.ToLower(zone) {
+	case timeFormatLocal:
+		format.Location = time.Local
+	case timeFormatUTC:
+		format.Location = time.UTC
+	default:
+		layout = spec
+	}
+
+	if layout = strings.TrimSpace(layout); layout != "" {
+		if layoutProbeTime.Format(layout) == layout {
+			return nil, fmt.Errorf("invalid time format %q, must be raw|local|utc|relative|delta|elapsed or a Go time layout such as 15:04:05.000", spec)
+		}
+		format.Layout = layout
+	}
+
+	return format, nil
+}
+
+// TimeFormatter renders entry timestamps in a TimeFormat. Delta and elapsed
+// timestamps depend on the entries shown before, so a TimeFormatter must see
+// every shown entry in display order.
+//
+// A TimeFormatter is not safe for concurrent use; it is owned by the single
+// printer goroutine. A nil TimeFormatter prints timestamps as logged.
+type TimeFormatter struct {
+	format   TimeFormat
+	now      func() time.Time
+	first    time.Time
+	previous time.Time
+	started  bool
+}
+
+func newTimeFormatter(format *TimeFormat) *TimeFormatter {
+	if format == nil || format.Mode == timeFormatRaw {
+		return nil
+	}
+	return &TimeFormatter{format: *format, now: time.Now}
+}
+
+// Reset forgets the entries seen so far, so delta and elapsed timestamps start
+// over (e.g. at the start of each --group-by group).
+func (f *TimeFormatter) Reset() {
+	if f == nil {
+		return
+	}
+	f.started = false
+}
+
+// Format returns the display text for the entry's timestamp and whether it
+// should be highlighted (a delta above the threshold). Timestamps that can't be
+// parsed are shown as logged.
+func (f *TimeFormatter) Format(entry *LogEntry) (string, bool) {
+	if f == nil {
+		return entry.Time, false
+	}
+
+	t, ok := parseEntryTime(entry)
+	if !ok {
+		return entry.Time, false
+	}
+
+	if !f.started {
+		f.first, f.previous, f.started = t, t, true
+	}
+	previous := f.previous
+	f.previous = t
+
+	switch f.format.Mode {
+	case timeFormatRelative:
+		return formatRelativeTime(f.now().Sub(t)), false
+	case timeFormatDelta:
+		delta := t.Sub(previous)
+		highlight := f.format.DeltaThreshold > 0 && delta > f.format.DeltaThreshold
+		return formatSignedDuration(delta), highlight
+	case timeFormatElapsed:
+		return formatSignedDuration(t.Sub(f.first)), false
+	}
+
+	if f.format.Location != nil {
+		t = t.In(f.format.Location)
+	}
+	return t.Format(f.format.Layout), false
+}
+
+// formatSignedDuration renders a duration with its sign, e.g. "+42ms", or
+// "-5ms" for an entry timestamped before the one it's compared to, as happens
+// when lines from several pods are interleaved.
+func formatSignedDuration(d time.Duration) string {
+	if d < 0 {
+		return formatShortDuration(d)
+	}
+	return "+" + formatShortDuration(d)
+}
+
+// formatShortDuration renders a duration compactly with a precision that suits
+// its size, e.g. "850µs", "42ms", "1.204s", "3m05s", "2h10m".
+func formatShortDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+
+	switch {
+	case d == 0:
+		return "0s"
+	case d < time.Millisecond:
+		return fmt.Sprintf("%s%dµs", sign, d.Microseconds())
+	case d < time.Second:
+		return fmt.Sprintf("%s%dms", sign, d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%s%.3fs", sign, d.Seconds())
+	case d < time.Hour:
+		return fmt.Sprintf("%s%dm%02ds", sign, int(d.Minutes()), int(d.Seconds())%60)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%s%dh%02dm", sign, int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%s%dd%02dh", sign, int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
+
+// formatRelativeTime renders how long ago something happened, e.g. "3m ago".
+func formatRelativeTime(ago time.Duration) string {
+	suffix := " ago"
+	if ago < 0 {
+		ago, suffix = -ago, " from now"
+	}
+
+	switch {
+	case ago < time.Second:
+		return "just now"
+	case ago < time.Minute:
+		return fmt.Sprintf("%ds%s", int(ago.Seconds()), suffix)
+	case ago < time.Hour:
+		return fmt.Sprintf("%dm%s", int(ago.Minutes()), suffix)
+	case ago < 24*time.Hour:
+		return fmt.Sprintf("%dh%s", int(ago.Hours()), suffix)
+	default:
+		return fmt.Sprintf("%dd%s", int(ago.Hours())/24, suffix)
+	}
+}
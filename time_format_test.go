@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeFormat(t *testing.T) {
+	tests := []struct {
+		name         string
+		spec         string
+		wantMode     string
+		wantLayout   string
+		wantLocation *time.Location
+	}{
+		{"empty is raw", "", timeFormatRaw, "", nil},
+		{"raw", "raw", timeFormatRaw, "", nil},
+		{"relative", "relative", timeFormatRelative, "", nil},
+		{"delta is case insensitive", "Delta", timeFormatDelta, "", nil},
+		{"elapsed", "elapsed", timeFormatElapsed, "", nil},
+		{"local keeps the full layout", "local", "", time.RFC3339Nano, time.Local},
+		{"utc with a layout", "utc 15:04:05", "", "15:04:05", time.UTC},
+		{"layout alone keeps the logged zone", "15:04:05.000", "", "15:04:05.000", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimeFormat(tt.spec, "")
+			if err != nil {
+				t.Fatalf("parseTimeFormat(%q) error = %v", tt.spec, err)
+			}
+			if got.Mode != tt.wantMode || got.Layout != tt.wantLayout || got.Location != tt.wantLocation {
+				t.Errorf("parseTimeFormat(%q) = %+v, want mode %q layout %q location %v", tt.spec, got, tt.wantMode, tt.wantLayout, tt.wantLocation)
+			}
+		})
+	}
+
+	t.Run("rejects a layout without any time element", func(t *testing.T) {
+		if _, err := parseTimeFormat("relativ", ""); err == nil {
+			t.Errorf("expected an error for a mistyped mode")
+		}
+	})
+
+	t.Run("rejects an invalid delta threshold", func(t *testing.T) {
+		if _, err := parseTimeFormat("delta", "soon"); err == nil {
+			t.Errorf("expected an error for an invalid threshold")
+		}
+	})
+}
+
+func TestTimeFormatter(t *testing.T) {
+	entry := func(ts string) *LogEntry { return &LogEntry{Time: ts} }
+
+	t.Run("nil formatter and raw mode print timestamps as logged", func(t *testing.T) {
+		var f *TimeFormatter
+		if got, _ := f.Format(entry("2026-06-25T12:00:01.123456789Z")); got != "2026-06-25T12:00:01.123456789Z" {
+			t.Errorf("Format() = %q, want the logged timestamp", got)
+		}
+		if newTimeFormatter(&TimeFormat{Mode: timeFormatRaw}) != nil {
+			t.Errorf("newTimeFormatter(raw) should return nil")
+		}
+	})
+
+	t.Run("formats with a layout and time zone", func(t *testing.T) {
+		f := newTimeFormatter(&TimeFormat{Layout: "15:04:05.000", Location: time.FixedZone("CEST", 2*60*60)})
+		if got, _ := f.Format(entry("2026-06-25T12:00:01.123456Z")); got != "14:00:01.123" {
+			t.Errorf("Format() = %q, want %q", got, "14:00:01.123")
+		}
+	})
+
+	t.Run("shows unparseable timestamps as logged", func(t *testing.T) {
+		f := newTimeFormatter(&TimeFormat{Mode: timeFormatDelta})
+		if got, _ := f.Format(entry("yesterday")); got != "yesterday" {
+			t.Errorf("Format() = %q, want %q", got, "yesterday")
+		}
+	})
+
+	t.Run("relative to now", func(t *testing.T) {
+		f := newTimeFormatter(&TimeFormat{Mode: timeFormatRelative})
+		f.now = func() time.Time { return time.Date(2026, 6, 25, 12, 3, 30, 0, time.UTC) }
+
+		if got, _ := f.Format(entry("2026-06-25T12:00:01Z")); got != "3m ago" {
+			t.Errorf("Format() = %q, want %q", got, "3m ago")
+		}
+	})
+
+	t.Run("delta since the previous entry, highlighted above the threshold", func(t *testing.T) {
+		f := newTimeFormatter(&TimeFormat{Mode: timeFormatDelta, DeltaThreshold: time.Second})
+
+		steps := []struct {
+			ts            string
+			want          string
+			wantHighlight bool
+		}{
+			{"2026-06-25T12:00:00Z", "+0s", false},
+			{"2026-06-25T12:00:00.250Z", "+250ms", false},
+			{"2026-06-25T12:00:03.250Z", "+3.000s", true},
+			{"2026-06-25T12:00:03.245Z", "-5ms", false},
+		}
+		for _, step := range steps {
+			got, highlight := f.Format(entry(step.ts))
+			if got != step.want || highlight != step.wantHighlight {
+				t.Errorf("Format(%s) = %q, %t, want %q, %t", step.ts, got, highlight, step.want, step.wantHighlight)
+			}
+		}
+	})
+
+	t.Run("elapsed since the first entry, restarting after Reset", func(t *testing.T) {
+		f := newTimeFormatter(&TimeFormat{Mode: timeFormatElapsed})
+
+		f.Format(entry("2026-06-25T12:00:00Z"))
+		if got, _ := f.Format(entry("2026-06-25T12:01:05Z")); got != "+1m05s" {
+			t.Errorf("Format() = %q, want %q", got, "+1m05s")
+		}
+
+		if got, _ := f.Format(entry("2026-06-25T11:59:58Z")); got != "-2.000s" {
+			t.Errorf("Format() before the first entry = %q, want %q", got, "-2.000s")
+		}
+
+		f.Reset()
+		if got, _ := f.Format(entry("2026-06-25T13:00:00Z")); got != "+0s" {
+			t.Errorf("Format() after Reset = %q, want %q", got, "+0s")
+		}
+	})
+}
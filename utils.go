@@ -3,3 +3,7 @@ package main
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func strPtr(s string) *string {
+	return &s
+}
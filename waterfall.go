@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// waterfallBarWidth is how many columns a span's duration bar spans for the
+// whole trace.
+const waterfallBarWidth = 20
+
+// waterfallIndent is how far each level of the span tree is indented.
+const waterfallIndent = "  "
+
+// spanNode is a span in a trace group's waterfall: the entries logged in it,
+// in time order, and the spans started from it.
+type spanNode struct {
+	ID       string
+	ParentID string
+	Entries  []*LogEntry
+	Children []*spanNode
+}
+
+// buildSpanTree arranges a group's entries into spans using the fields in
+// SpanKeywords and ParentSpanKeywords. A span is nested under its parent when
+// the parent logged in the same group; otherwise it's a root. Spans are kept in
+// the order of their first entry, so entries should be sorted by time. Entries
+// without a span id are returned separately.
+func buildSpanTree(entries []*LogEntry, keywords KeywordConfig) (roots []*spanNode, unspanned []*LogEntry) {
+	var spans []*spanNode
+	index := make(map[string]*spanNode)
+
+	for _, entry := range entries {
+		id, ok := groupKeyFor(entry, keywords.SpanKeywords)
+		if !ok {
+			unspanned = append(unspanned, entry)
+			continue
+		}
+
+		span, exists := index[id]
+		if !exists {
+			span = &spanNode{ID: id}
+			index[id] = span
+			spans = append(spans, span)
+		}
+		span.Entries = append(span.Entries, entry)
+
+		if span.ParentID == "" {
+			if parentID, ok := groupKeyFor(entry, keywords.ParentSpanKeywords); ok && parentID != id {
+				span.ParentID = parentID
+			}
+		}
+	}
+
+	for _, span := range spans {
+		parent, ok := index[span.ParentID]
+		if ok && !descendsFrom(parent, span, index) {
+			parent.Children = append(parent.Children, span)
+		} else {
+			roots = append(roots, span)
+		}
+	}
+
+	return roots, unspanned
+}
+
+// descendsFrom reports whether span is ancestor or one of its ancestors, so
+// spans whose parent ids form a cycle don't nest under each other forever.
+func descendsFrom(span, ancestor *spanNode, index map[string]*spanNode) bool {
+	seen := make(map[*spanNode]struct{})
+	for span != nil {
+		if span == ancestor {
+			return true
+		}
+		if _, ok := seen[span]; ok {
+			return false
+		}
+		seen[span] = struct{}{}
+		span = index[span.ParentID]
+	}
+	return false
+}
+
+// hasSpans reports whether any entry carries a span id.
+func hasSpans(entries []*LogEntry, keywords KeywordConfig) bool {
+	for _, entry := range entries {
+		if _, ok := groupKeyFor(entry, keywords.SpanKeywords); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// timeRange returns the first and last parseable timestamps of the entries.
+func timeRange(entries []*LogEntry) (first, last time.Time, ok bool) {
+	for _, entry := range entries {
+		t, parsed := parseEntryTime(entry)
+		if !parsed {
+			continue
+		}
+		if !ok || t.Before(first) {
+			first = t
+		}
+		if !ok || t.After(last) {
+			last = t
+		}
+		ok = true
+	}
+	return first, last, ok
+}
+
+// durationBar draws a span running from start to end within a trace running
+// from traceStart to traceEnd, e.g. "    ██████          ". Spans too short
+// to fill a column still get one.
+func durationBar(start, end, traceStart, traceEnd time.Time, width int) string {
+	offset, length := 0, width
+
+	if total := traceEnd.Sub(traceStart); total > 0 {
+		offset = int(float64(start.Sub(traceStart)) / float64(total) * float64(width))
+		length = int(math.Ceil(float64(end.Sub(start)) / float64(total) * float64(width)))
+		if length < 1 {
+			length = 1
+		}
+		if offset+length > width {
+			offset = width - length
+		}
+	}
+
+	return strings.Repeat(" ", offset) + strings.Repeat("█", length) + strings.Repeat(" ", width-offset-length)
+}
+
+// spanHeaderText is the text of a span's header, e.g.
+// "▸ span=a1b2 · booking-api · 42ms".
+func spanHeaderText(span *spanNode) string {
+	parts := []string{"span=" + span.ID}
+
+	if path := hopPath(span.Entries); len(path) > 0 {
+		parts = append(parts, strings.Join(path, " → "))
+	}
+	if first, last, ok := timeRange(span.Entries); ok {
+		parts = append(parts, formatDuration(last.Sub(first)))
+	}
+
+	return "▸ " + strings.Join(parts, " · ")
+}
+
+func spanHeaderStyle() *color.Color {
+	return newColor(color.FgHiWhite)
+}
+
+// waterfallRow is a span header in the order the waterfall prints them.
+type waterfallRow struct {
+	span  *spanNode
+	depth int
+	text  string
+}
+
+func flattenSpanTree(spans []*spanNode, depth int, rows []waterfallRow) []waterfallRow {
+	for _, span := range spans {
+		rows = append(rows, waterfallRow{span: span, depth: depth, text: spanHeaderText(span)})
+		rows = flattenSpanTree(span.Children, depth+1, rows)
+	}
+	return rows
+}
+
+// printWaterfall prints a trace group's entries nested under their spans, each
+// span indented under its parent with a bar showing when it ran within the
+// trace. The bars line up in a column after the span headers. Entries without
+// a span id are printed first. Everything is indented by indent, for nested
+// groups.
+func printWaterfall(args Args, config Config, group *traceGroup, indent string, colorizer *PodColorizer, timeFormatter *TimeFormatter) {
+	roots, unspanned := buildSpanTree(group.Entries, *config.Keywords)
+
+	for _, entry := range unspanned {
+		printIndentedEntry(args, config, entry, indent, colorizer, timeFormatter)
+	}
+
+	rows := flattenSpanTree(roots, 0, nil)
+	headerWidth := 0
+	for _, row := range rows {
+		if width := len(waterfallIndent)*row.depth + visibleWidth(row.text); width > headerWidth {
+			headerWidth = width
+		}
+	}
+
+	traceStart, traceEnd, traceTimed := timeRange(group.Entries)
+
+	for _, row := range rows {
+		spanIndent := strings.Repeat(waterfallIndent, row.depth)
+		header := spanHeaderStyle().Sprint(row.text)
+
+		if start, end, ok := timeRange(row.span.Entries); ok && traceTimed {
+			padding := strings.Repeat(" ", headerWidth-len(spanIndent)-visibleWidth(row.text))
+			header += padding + "  " + spanBarColor(config, row.span, colorizer).Sprint(durationBar(start, end, traceStart, traceEnd, waterfallBarWidth))
+		}
+		fmt.Println(indent + spanIndent + header)
+
+		for _, entry := range row.span.Entries {
+			printIndentedEntry(args, config, entry, indent+spanIndent+waterfallIndent, colorizer, timeFormatter)
+		}
+	}
+}
+
+// spanBarColor colors a span's bar like the pod prefix of its lines.
+func spanBarColor(config Config, span *spanNode, colorizer *PodColorizer) *color.Color {
+	if colorizer != nil {
+		for _, entry := range span.Entries {
+			if entry.PodID != "" {
+				return colorizer.colorFor(podLabel(config, entry))
+			}
+		}
+	}
+	return spanHeaderStyle()
+}
+
+// indentLines prefixes every line of s with indent.
+func indentLines(s, indent string) string {
+	return indent + strings.ReplaceAll(s, "\n", "\n"+indent)
+}
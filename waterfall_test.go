@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func spanIDs(spans []*spanNode) []string {
+	ids := make([]string, 0, len(spans))
+	for _, span := range spans {
+		ids = append(ids, span.ID)
+	}
+	return ids
+}
+
+func TestBuildSpanTree(t *testing.T) {
+	keywords := *newDefaultConfig().Keywords
+
+	t.Run("nests spans under their parents across field name styles", func(t *testing.T) {
+		entries := []*LogEntry{
+			groupTestEntry(1, "gateway-x", "2026-06-25T12:00:00Z", map[string]string{"span.id": "a"}),
+			groupTestEntry(2, "booking-y", "2026-06-25T12:00:01Z", map[string]string{"span.id": "b", "parent.id": "a"}),
+			groupTestEntry(3, "payment-z", "2026-06-25T12:00:02Z", map[string]string{"span_id": "c", "parent_span_id": "b"}),
+			groupTestEntry(4, "booking-y", "2026-06-25T12:00:03Z", map[string]string{"span.id": "b", "parent.id": "a"}),
+			groupTestEntry(5, "gateway-x", "2026-06-25T12:00:04Z", map[string]string{"span.id": "d", "parent.id": "a"}),
+		}
+
+		roots, unspanned := buildSpanTree(entries, keywords)
+
+		if len(unspanned) != 0 || len(roots) != 1 || roots[0].ID != "a" {
+			t.Fatalf("roots = %v, unspanned = %d, want a single root a", spanIDs(roots), len(unspanned))
+		}
+		if got := spanIDs(roots[0].Children); len(got) != 2 || got[0] != "b" || got[1] != "d" {
+			t.Errorf("children of a = %v, want [b d]", got)
+		}
+		b := roots[0].Children[0]
+		if len(b.Entries) != 2 || b.Entries[1].LineNumber != 4 {
+			t.Errorf("span b has %d entries, want lines 2 and 4", len(b.Entries))
+		}
+		if got := spanIDs(b.Children); len(got) != 1 || got[0] != "c" {
+			t.Errorf("children of b = %v, want [c]", got)
+		}
+	})
+
+	t.Run("makes spans whose parent didn't log roots and returns entries without a span", func(t *testing.T) {
+		entries := []*LogEntry{
+			groupTestEntry(1, "", "", map[string]string{"span.id": "b", "parent.id": "elsewhere"}),
+			groupTestEntry(2, "", "", map[string]string{"trace.id": "t"}),
+			groupTestEntry(3, "", "", map[string]string{"span.id": "c"}),
+		}
+
+		roots, unspanned := buildSpanTree(entries, keywords)
+
+		if got := spanIDs(roots); len(got) != 2 || got[0] != "b" || got[1] != "c" {
+			t.Errorf("roots = %v, want [b c]", got)
+		}
+		if len(unspanned) != 1 || unspanned[0].LineNumber != 2 {
+			t.Errorf("unspanned = %v, want line 2", unspanned)
+		}
+	})
+
+	t.Run("doesn't nest spans whose parents form a cycle forever", func(t *testing.T) {
+		entries := []*LogEntry{
+			groupTestEntry(1, "", "", map[string]string{"span.id": "a", "parent.id": "b"}),
+			groupTestEntry(2, "", "", map[string]string{"span.id": "b", "parent.id": "a"}),
+		}
+
+		roots, _ := buildSpanTree(entries, keywords)
+
+		if got := spanIDs(roots); len(got) != 2 || len(roots[0].Children) != 0 || len(roots[1].Children) != 0 {
+			t.Errorf("roots = %v, want a and b side by side", got)
+		}
+	})
+}
+
+func TestDurationBar(t *testing.T) {
+	start := time.Date(2026, 6, 25, 12, 0, 0, 0, time.UTC)
+	end := start.Add(100 * time.Millisecond)
+
+	tests := []struct {
+		name       string
+		from, to   time.Duration
+		traceStart time.Time
+		traceEnd   time.Time
+		want       string
+	}{
+		{"whole trace", 0, 100 * time.Millisecond, start, end, "██████████"},
+		{"middle of the trace", 20 * time.Millisecond, 60 * time.Millisecond, start, end, "  ████    "},
+		{"instant span gets a column", 50 * time.Millisecond, 50 * time.Millisecond, start, end, "     █    "},
+		{"instant span at the end stays in the bar", 100 * time.Millisecond, 100 * time.Millisecond, start, end, "         █"},
+		{"trace without a duration", 0, 0, start, start, "██████████"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := durationBar(start.Add(tt.from), start.Add(tt.to), tt.traceStart, tt.traceEnd, 10)
+			if got != tt.want {
+				t.Errorf("durationBar() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpanHeaderText(t *testing.T) {
+	span := &spanNode{ID: "b2", Entries: []*LogEntry{
+		groupTestEntry(1, "booking-api-7d8f9b6c5-x2k4p", "2026-06-25T12:00:00.010Z", nil),
+		groupTestEntry(2, "booking-api-7d8f9b6c5-x2k4p", "2026-06-25T12:00:00.052Z", nil),
+	}}
+
+	want := "▸ span=b2 · booking-api · 42ms"
+	if got := spanHeaderText(span); got != want {
+		t.Errorf("spanHeaderText() = %q, want %q", got, want)
+	}
+}
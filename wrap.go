@@ -0,0 +1,347 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minWrapColumn is the least room after the hanging indent that is worth
+// wrapping into. When the head of a line leaves less than this, continuation
+// lines fall back to fallbackWrapIndent instead of aligning under the message.
+const (
+	minWrapColumn      = 20
+	fallbackWrapIndent = 4
+)
+
+const ansiReset = "\x1b[0m"
+
+// wrapSegment is one unit of a line laid out by softWrap. Sep is written before
+// Text unless the segment starts a continuation line.
+type wrapSegment struct {
+	Sep  string
+	Text string
+}
+
+// softWrap lays out segments after head so no line is wider than width
+// columns, not counting ANSI escape codes. Lines are broken between segments
+// first; a segment that doesn't fit on a line of its own is broken at
+// whitespace, and only as a last resort mid-word. A segment's Sep is only
+// written when the segment continues the line, so lines never end in a
+// separator. Continuation lines are indented to the width of head, so text
+// stays aligned under the first segment. A width of 0 (or anything too narrow
+// to wrap into) disables wrapping.
+func softWrap(head string, segments []wrapSegment, width int) string {
+	if width < minWrapColumn {
+		var b strings.Builder
+		b.WriteString(head)
+		for _, segment := range segments {
+			b.WriteString(segment.Sep + segment.Text)
+		}
+		return b.String()
+	}
+
+	indentWidth := visibleWidth(head)
+	if width-indentWidth < minWrapColumn {
+		indentWidth = fallbackWrapIndent
+	}
+	indent := strings.Repeat(" ", indentWidth)
+	room := width - indentWidth
+
+	var b strings.Builder
+	b.WriteString(head)
+	column := visibleWidth(head)
+	atLineStart := true
+
+	newLine := func() {
+		b.WriteString("\n" + indent)
+		column = indentWidth
+		atLineStart = true
+	}
+
+	for _, segment := range segments {
+		text := segment.Text
+
+		for text != "" {
+			sep := ""
+			if !atLineStart {
+				sep = segment.Sep
+			}
+			available := width - column - visibleWidth(sep)
+			textWidth := visibleWidth(text)
+
+			if textWidth <= available {
+				b.WriteString(sep + text)
+				column += visibleWidth(sep) + textWidth
+				atLineStart = false
+				break
+			}
+
+			// Start a new line for a segment that fits on one of its own, or
+			// when this line has no room left, e.g. after a head wider than
+			// the line.
+			if (!atLineStart && textWidth <= room) || available < 1 {
+				newLine()
+				continue
+			}
+
+			cut := lastSpaceWithin(text, available)
+			if cut <= 0 {
+				if !atLineStart {
+					newLine()
+					continue
+				}
+				cut = available
+			}
+
+			first, rest := splitANSI(text, cut)
+			b.WriteString(sep + first)
+			atLineStart = false
+			text = trimLeadingANSISpace(rest)
+			if text != "" {
+				newLine()
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// ansiSequenceLen returns the length of the ANSI CSI escape sequence (e.g. an
+// SGR color code) or OSC sequence (e.g. an OSC 8 hyperlink) at the start of s,
+// or 0 if s doesn't start with one.
+func ansiSequenceLen(s string) int {
+	if strings.HasPrefix(s, "\x1b]") {
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return 0
+	}
+
+	if !strings.HasPrefix(s, "\x1b[") {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// stripANSI removes ANSI escape sequences from s.
+func stripANSI(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if n := ansiSequenceLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// visibleWidth is the number of columns s occupies in a terminal, not
+// counting ANSI escape sequences. See runeWidth for how wide each rune is.
+func visibleWidth(s string) int {
+	width := 0
+	for _, r := range stripANSI(s) {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// wideRunes are the ranges of runes that terminals draw two columns wide: the
+// East Asian Wide and Fullwidth blocks (CJK, Hangul, kana, fullwidth forms) and
+// emoji.
+var wideRunes = [][2]rune{
+	{0x1100, 0x115F},
+	{0x231A, 0x231B},
+	{0x2329, 0x232A},
+	{0x23E9, 0x23EC},
+	{0x23F0, 0x23F0},
+	{0x23F3, 0x23F3},
+	{0x25FD, 0x25FE},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x26A1, 0x26A1},
+	{0x26AA, 0x26AB},
+	{0x26BD, 0x26BE},
+	{0x26C4, 0x26C5},
+	{0x26D4, 0x26D4},
+	{0x26EA, 0x26EA},
+	{0x26F2, 0x26F5},
+	{0x26FA, 0x26FD},
+	{0x2705, 0x2705},
+	{0x270A, 0x270B},
+	{0x2728, 0x2728},
+	{0x274C, 0x274C},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27B0, 0x27B0},
+	{0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50},
+	{0x2B55, 0x2B55},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xA960, 0xA97F},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x16FE0, 0x16FE4},
+	{0x17000, 0x18CFF},
+	{0x1B000, 0x1B2FF},
+	{0x1F004, 0x1F004},
+	{0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E},
+	{0x1F191, 0x1F19A},
+	{0x1F200, 0x1F251},
+	{0x1F300, 0x1F64F},
+	{0x1F680, 0x1F6FF},
+	{0x1F7E0, 0x1F7EB},
+	{0x1F90C, 0x1F9FF},
+	{0x1FA70, 0x1FAFF},
+	{0x20000, 0x3FFFD},
+}
+
+// runeWidth is how many columns a terminal draws r in: 0 for combining marks
+// and invisible formatting characters such as zero-width joiners, 2 for wide
+// runes, and 1 for everything else. Like most terminals, it doesn't treat
+// emoji sequences (e.g. flags or joined emoji) as one glyph, so those may be
+// measured wider than they're drawn.
+func runeWidth(r rune) int {
+	if r == 0 || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	if r < wideRunes[0][0] {
+		return 1
+	}
+
+	i := sort.Search(len(wideRunes), func(i int) bool { return wideRunes[i][1] >= r })
+	if i < len(wideRunes) && wideRunes[i][0] <= r {
+		return 2
+	}
+	return 1
+}
+
+func isANSIReset(sequence string) bool {
+	return sequence == ansiReset || sequence == "\x1b[m"
+}
+
+// isHyperlinkSequence reports whether sequence opens or closes an OSC 8
+// hyperlink.
+func isHyperlinkSequence(sequence string) bool {
+	return strings.HasPrefix(sequence, "\x1b]8;")
+}
+
+// splitANSI cuts s after n columns, keeping a wide rune that would straddle
+// the cut in the tail. The head always gets at least one rune, so a line too
+// narrow for a wide rune still makes progress. Styling and hyperlinks active at the
+// cut are closed at the end of head and re-opened at the start of tail, so each
+// half renders on its own line exactly as it would have unsplit.
+func splitANSI(s string, n int) (head, tail string) {
+	var active []string
+	link := ""
+	visible := 0
+	taken := false
+	i := 0
+
+	for i < len(s) {
+		if seqLen := ansiSequenceLen(s[i:]); seqLen > 0 {
+			sequence := s[i : i+seqLen]
+			switch {
+			case isHyperlinkSequence(sequence):
+				link = sequence
+				if sequence == osc8Close {
+					link = ""
+				}
+			case isANSIReset(sequence):
+				active = nil
+			default:
+				active = append(active, sequence)
+			}
+			i += seqLen
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		width := runeWidth(r)
+		if visible+width > n && taken {
+			break
+		}
+
+		i += size
+		visible += width
+		taken = true
+	}
+
+	head, tail = s[:i], s[i:]
+	if len(active) > 0 && tail != "" {
+		head += ansiReset
+		tail = strings.Join(active, "") + tail
+	}
+	if link != "" && tail != "" {
+		head += osc8Close
+		tail = link + tail
+	}
+	return head, tail
+}
+
+// lastSpaceWithin returns the column at which the last run of whitespace in s
+// starts that leaves at most maxWidth columns before it, or -1 if there is
+// none.
+func lastSpaceWithin(s string, maxWidth int) int {
+	position := -1
+	column := 0
+	inSpace := false
+	for _, r := range stripANSI(s) {
+		if column > maxWidth {
+			break
+		}
+		if unicode.IsSpace(r) {
+			if !inSpace {
+				position = column
+			}
+			inSpace = true
+		} else {
+			inSpace = false
+		}
+		column += runeWidth(r)
+	}
+	return position
+}
+
+// trimLeadingANSISpace drops the leading whitespace from s, looking past any
+// escape sequences in front of it.
+func trimLeadingANSISpace(s string) string {
+	i := 0
+	for {
+		n := ansiSequenceLen(s[i:])
+		if n == 0 {
+			break
+		}
+		i += n
+	}
+
+	return s[:i] + strings.TrimLeftFunc(s[i:], unicode.IsSpace)
+}
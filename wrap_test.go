@@ -0,0 +1,188 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVisibleWidth(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"\x1b[31mred\x1b[0m → ok", 8},
+		{"日本語", 6},
+		{"ok 👍", 5},
+		{"cafe\u0301", 4},
+	}
+
+	for _, tt := range tests {
+		if got := visibleWidth(tt.text); got != tt.want {
+			t.Errorf("visibleWidth(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplitANSI(t *testing.T) {
+	t.Run("closes and reopens styling active at the cut", func(t *testing.T) {
+		head, tail := splitANSI("\x1b[31mhello world\x1b[0m", 5)
+
+		if head != "\x1b[31mhello\x1b[0m" {
+			t.Errorf("head = %q", head)
+		}
+		if tail != "\x1b[31m world\x1b[0m" {
+			t.Errorf("tail = %q", tail)
+		}
+	})
+
+	t.Run("doesn't reopen styling that was reset before the cut", func(t *testing.T) {
+		head, tail := splitANSI("\x1b[31mab\x1b[0mcd", 3)
+
+		if head != "\x1b[31mab\x1b[0mc" || tail != "d" {
+			t.Errorf("splitANSI() = %q, %q", head, tail)
+		}
+	})
+
+	t.Run("keeps a wide rune straddling the cut in the tail", func(t *testing.T) {
+		head, tail := splitANSI("日本語", 3)
+
+		if head != "日" || tail != "本語" {
+			t.Errorf("splitANSI() = %q, %q", head, tail)
+		}
+	})
+
+	t.Run("closes and reopens a hyperlink active at the cut", func(t *testing.T) {
+		open := "\x1b]8;;https://example.com\x1b\\"
+		head, tail := splitANSI(open+"hello world"+osc8Close, 5)
+
+		if head != open+"hello"+osc8Close {
+			t.Errorf("head = %q", head)
+		}
+		if tail != open+" world"+osc8Close {
+			t.Errorf("tail = %q", tail)
+		}
+	})
+}
+
+func TestSoftWrap(t *testing.T) {
+	t.Run("leaves lines alone when wrapping is disabled", func(t *testing.T) {
+		got := softWrap("head - ", []wrapSegment{{Text: "message"}, {Sep: " - ", Text: "a=[1]"}}, 0)
+		if got != "head - message - a=[1]" {
+			t.Errorf("softWrap() = %q", got)
+		}
+	})
+
+	t.Run("breaks between fields first and indents under the message", func(t *testing.T) {
+		segments := []wrapSegment{
+			{Text: "short message"},
+			{Sep: " - ", Text: "alpha=[1]"},
+			{Sep: " ", Text: "beta=[2]"},
+			{Sep: " ", Text: "gamma=[3]"},
+		}
+
+		got := softWrap("[info] 12:00 - ", segments, 48)
+		want := strings.Join([]string{
+			"[info] 12:00 - short message - alpha=[1]",
+			"               beta=[2] gamma=[3]",
+		}, "\n")
+
+		if got != want {
+			t.Errorf("softWrap() =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("word-wraps a message that is too long for one line", func(t *testing.T) {
+		got := softWrap("> ", []wrapSegment{{Text: "the quick brown fox jumps over the lazy dog"}}, 22)
+		want := strings.Join([]string{
+			"> the quick brown fox",
+			"  jumps over the lazy",
+			"  dog",
+		}, "\n")
+
+		if got != want {
+			t.Errorf("softWrap() =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("hard-splits words longer than a line", func(t *testing.T) {
+		got := softWrap("", []wrapSegment{{Text: strings.Repeat("x", 25)}}, 20)
+		want := strings.Repeat("x", 20) + "\n" + strings.Repeat("x", 5)
+
+		if got != want {
+			t.Errorf("softWrap() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("falls back to a small indent when the head leaves too little room", func(t *testing.T) {
+		got := softWrap(strings.Repeat("h", 15), []wrapSegment{{Text: "aaaa bbbb cccc dddd"}}, 30)
+		want := strings.Repeat("h", 15) + "aaaa bbbb cccc\n    dddd"
+
+		if got != want {
+			t.Errorf("softWrap() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("doesn't count escape codes towards the width", func(t *testing.T) {
+		styled := "\x1b[32m" + strings.Repeat("y", 20) + "\x1b[0m"
+		got := softWrap("", []wrapSegment{{Text: styled}}, 20)
+
+		if got != styled {
+			t.Errorf("softWrap() = %q, want the styled text unwrapped", got)
+		}
+	})
+
+	t.Run("doesn't end a line with the separator of the segment moved to the next", func(t *testing.T) {
+		segments := []wrapSegment{
+			{Text: "message is here"},
+			{Sep: " ", Text: "log.origin.file.line=[47]"},
+			{Sep: " - ", Text: "user=[ada]"},
+		}
+
+		got := softWrap("[info] 12:00 - ", segments, 40)
+		want := strings.Join([]string{
+			"[info] 12:00 - message is here",
+			"               log.origin.file.line=[47]",
+			"               user=[ada]",
+		}, "\n")
+
+		if got != want {
+			t.Errorf("softWrap() =\n%q\nwant\n%q", got, want)
+		}
+	})
+
+	t.Run("breaks a field value too long for a line at whitespace", func(t *testing.T) {
+		segments := []wrapSegment{
+			{Text: "msg"},
+			{Sep: " - ", Text: "note=[a b c d e f g h i j k l m n o p q r s t u v w]"},
+		}
+
+		got := softWrap("[info] 12:00 - ", segments, 40)
+		want := strings.Join([]string{
+			"[info] 12:00 - msg - note=[a b c d e f g",
+			"               h i j k l m n o p q r s t",
+			"               u v w]",
+		}, "\n")
+
+		if got != want {
+			t.Errorf("softWrap() =\n%q\nwant\n%q", got, want)
+		}
+	})
+
+	t.Run("starts on a new line after a head wider than the line", func(t *testing.T) {
+		head := strings.Repeat("h", 50)
+		got := softWrap(head, []wrapSegment{{Text: "abc def"}}, 40)
+
+		if want := head + "\n    abc def"; got != want {
+			t.Errorf("softWrap() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("wraps wide runes by the columns they take", func(t *testing.T) {
+		got := softWrap("> ", []wrapSegment{{Text: "日本語のテキストはとても長いですね本当に"}}, 22)
+		want := "> 日本語のテキストはと\n  ても長いですね本当に"
+
+		if got != want {
+			t.Errorf("softWrap() = %q, want %q", got, want)
+		}
+	})
+}